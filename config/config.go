@@ -1,18 +1,41 @@
 package config
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
 
 // Global Configuration
 const (
-	GbeDir        = ".local/share/gbe_fork"
-	SteamStoreAPI = "https://store.steampowered.com/api"
-	GithubAPIURL  = "https://api.github.com/repos/Detanup01/gbe_fork/releases/latest"
-	SevenZCommand = "7z"
+	GbeDir            = ".local/share/gbe_fork"
+	SteamStoreAPI     = "https://store.steampowered.com/api"
+	GithubAPIURL      = "https://api.github.com/repos/Detanup01/gbe_fork/releases/latest"
+	GithubReleasesURL = "https://api.github.com/repos/Detanup01/gbe_fork/releases"
+	SevenZCommand     = "7z"
+	SteamWebAPI       = "https://api.steampowered.com"
+	SteamCommunityURL = "https://steamcommunity.com"
+	ProfileFileName   = "profile.json"
 )
 
+// DefaultMetadataProviders is the metadata provider order used when the
+// profile does not set one.
+var DefaultMetadataProviders = []string{"override", "appinfo", "store", "webapi"}
+
+// DefaultFlavour is the build flavour applied when none is selected.
+const DefaultFlavour = "experimental"
+
 // PlatformConfig maps platform names to their configuration.
+// Asset is the suffix of the release asset providing Subdir, and Format is
+// the archive format of that asset. Flavours maps each build flavour to the
+// directory holding its files, relative to GbeDir. Loader is the
+// ColdClientLoader executable found in LoaderDir, for platforms that have one.
 var PlatformConfig = map[string]struct {
-	Subdir, Target, Additional, Generator, Arch string
+	Subdir, Target, Additional, Generator, Arch, Asset, Format string
+	Loader, LoaderDir                                          string
+	Flavours                                                   map[string]string
 }{
 	"linux": {
 		Subdir:     "linux_release",
@@ -20,6 +43,13 @@ var PlatformConfig = map[string]struct {
 		Additional: "steamclient.so",
 		Generator:  "generate_interfaces_x64",
 		Arch:       "64",
+		Asset:      "linux-release.tar.bz2",
+		Format:     "tar.bz2",
+		Flavours: map[string]string{
+			"regular":      "linux_release/regular/x64",
+			"experimental": "linux_release/experimental/x64",
+			"debug":        "linux_debug/experimental/x64",
+		},
 	},
 	"win64": {
 		Subdir:     "win_release",
@@ -27,6 +57,15 @@ var PlatformConfig = map[string]struct {
 		Additional: "steamclient64.dll",
 		Generator:  "generate_interfaces_x64.exe",
 		Arch:       "64",
+		Asset:      "win-release.7z",
+		Format:     "7z",
+		Loader:     "steamclient_loader_x64.exe",
+		LoaderDir:  "win_release/steamclient_experimental",
+		Flavours: map[string]string{
+			"regular":      "win_release/regular/x64",
+			"experimental": "win_release/experimental/x64",
+			"debug":        "win_debug/experimental/x64",
+		},
 	},
 	"win32": {
 		Subdir:     "win_release",
@@ -34,6 +73,42 @@ var PlatformConfig = map[string]struct {
 		Additional: "steamclient.dll",
 		Generator:  "generate_interfaces_x32.exe",
 		Arch:       "32",
+		Asset:      "win-release.7z",
+		Format:     "7z",
+		Loader:     "steamclient_loader_x32.exe",
+		LoaderDir:  "win_release/steamclient_experimental",
+		Flavours: map[string]string{
+			"regular":      "win_release/regular/x32",
+			"experimental": "win_release/experimental/x32",
+			"debug":        "win_debug/experimental/x32",
+		},
+	},
+}
+
+// ExtraAssets maps optional release assets to their download configuration.
+// Unlike the platform assets, a missing extra asset is not an error.
+var ExtraAssets = map[string]struct {
+	Subdir, Asset, Format string
+}{
+	"linux-debug": {
+		Subdir: "linux_debug",
+		Asset:  "linux-debug.tar.bz2",
+		Format: "tar.bz2",
+	},
+	"win-debug": {
+		Subdir: "win_debug",
+		Asset:  "win-debug.7z",
+		Format: "7z",
+	},
+	"gen_emu_config-linux": {
+		Subdir: "gen_emu_config_linux",
+		Asset:  "gen_emu_config-linux.tar.bz2",
+		Format: "tar.bz2",
+	},
+	"gen_emu_config-win": {
+		Subdir: "gen_emu_config_win",
+		Asset:  "gen_emu_config-win.7z",
+		Format: "7z",
 	},
 }
 
@@ -43,6 +118,222 @@ type Release struct {
 		Name               string `json:"name"`
 		BrowserDownloadURL string `json:"browser_download_url"`
 	} `json:"assets"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	PublishedAt time.Time `json:"published_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Body        string    `json:"body"`
+}
+
+// Profile holds the user's preferences, stored in GbeDir/profile.json.
+type Profile struct {
+	// MetadataProviders lists the app metadata sources in priority order:
+	// override, appinfo, store and webapi.
+	MetadataProviders []string `json:"metadata_providers,omitempty"`
+	// WebAPIKey is the Steam Web API key used by the webapi provider.
+	WebAPIKey string `json:"web_api_key,omitempty"`
+	// UnlockAllDLCs sets unlock_all=1 in configs.app.ini for games that do
+	// not choose themselves.
+	UnlockAllDLCs bool `json:"unlock_all_dlcs,omitempty"`
+	// Main holds the configs.main.ini defaults for every game.
+	Main MainSettings `json:"main"`
+	// Overlay holds the configs.overlay.ini settings for every game.
+	Overlay OverlaySettings `json:"overlay"`
+	// SubscribedGroups lists the Steam groups the emulated user is in.
+	SubscribedGroups []Group `json:"subscribed_groups,omitempty"`
+	// InstalledApps lists AppIDs reported as installed in every game, on top
+	// of those installed in the Steam libraries.
+	InstalledApps []string `json:"installed_apps,omitempty"`
+}
+
+// Group is a Steam group. Groups with a name are also reported as clans.
+type Group struct {
+	// ID is the group's SteamID64, e.g. 103582791429521412.
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+// MainSettings holds the emulator options written to configs.main.ini and
+// custom_broadcasts.txt. Unset fields leave the emulator's defaults.
+type MainSettings struct {
+	// Offline makes the emulator pretend Steam is offline.
+	Offline *bool `json:"offline,omitempty"`
+	// DisableNetworking turns off all networking of the emulator.
+	DisableNetworking *bool `json:"disable_networking,omitempty"`
+	// DisableLANOnly lets the emulator connect to peers outside the LAN.
+	DisableLANOnly *bool `json:"disable_lan_only,omitempty"`
+	// ListenPort is the port the emulator listens on for peers.
+	ListenPort int `json:"listen_port,omitempty"`
+	// EnableAccountAvatar makes the emulator load the account avatar.
+	EnableAccountAvatar *bool `json:"enable_account_avatar,omitempty"`
+	// SteamDeck makes the emulator report running on a Steam Deck.
+	SteamDeck *bool `json:"steam_deck,omitempty"`
+	// AchievementsBypass lets achievements be unlocked even if the game
+	// checks their stats first.
+	AchievementsBypass *bool `json:"achievements_bypass,omitempty"`
+	// Broadcasts lists the peer IPs or hostnames the emulator broadcasts to,
+	// for networks where LAN discovery does not reach them.
+	Broadcasts []string `json:"custom_broadcasts,omitempty"`
+	// Extra sets any other configs.main.ini key, by section and key.
+	Extra map[string]map[string]string `json:"extra,omitempty"`
+}
+
+// Merge returns the settings with those set in other taking precedence.
+func (s MainSettings) Merge(other MainSettings) MainSettings {
+	for _, field := range []struct{ dst, src **bool }{
+		{&s.Offline, &other.Offline},
+		{&s.DisableNetworking, &other.DisableNetworking},
+		{&s.DisableLANOnly, &other.DisableLANOnly},
+		{&s.EnableAccountAvatar, &other.EnableAccountAvatar},
+		{&s.SteamDeck, &other.SteamDeck},
+		{&s.AchievementsBypass, &other.AchievementsBypass},
+	} {
+		if *field.src != nil {
+			*field.dst = *field.src
+		}
+	}
+	if other.ListenPort != 0 {
+		s.ListenPort = other.ListenPort
+	}
+	if other.Broadcasts != nil {
+		s.Broadcasts = other.Broadcasts
+	}
+	s.Extra = mergeSections(s.Extra, other.Extra)
+	return s
+}
+
+// OverlaySettings holds the options written to configs.overlay.ini for the
+// experimental build's overlay. Unset fields leave the emulator's defaults.
+type OverlaySettings struct {
+	// Enabled turns the overlay on.
+	Enabled *bool `json:"enabled,omitempty"`
+	// DisableAchievementNotification hides the achievement unlock notifications.
+	DisableAchievementNotification *bool `json:"disable_achievement_notification,omitempty"`
+	// DisableFriendNotification hides the friend notifications.
+	DisableFriendNotification *bool `json:"disable_friend_notification,omitempty"`
+	// DisableAchievementProgress hides the achievement progress notifications.
+	DisableAchievementProgress *bool `json:"disable_achievement_progress,omitempty"`
+	// DisableWarnings hides every overlay warning.
+	DisableWarnings *bool `json:"disable_warning_any,omitempty"`
+	// HookDelay is the number of seconds to wait before hooking the renderer.
+	HookDelay int `json:"hook_delay_sec,omitempty"`
+	// Font is the file name of a font in the shared overlay fonts directory.
+	Font string `json:"font,omitempty"`
+	// Extra sets any other configs.overlay.ini key, by section and key.
+	Extra map[string]map[string]string `json:"extra,omitempty"`
+}
+
+// Merge returns the settings with those set in other taking precedence.
+func (s OverlaySettings) Merge(other OverlaySettings) OverlaySettings {
+	for _, field := range []struct{ dst, src **bool }{
+		{&s.Enabled, &other.Enabled},
+		{&s.DisableAchievementNotification, &other.DisableAchievementNotification},
+		{&s.DisableFriendNotification, &other.DisableFriendNotification},
+		{&s.DisableAchievementProgress, &other.DisableAchievementProgress},
+		{&s.DisableWarnings, &other.DisableWarnings},
+	} {
+		if *field.src != nil {
+			*field.dst = *field.src
+		}
+	}
+	if other.HookDelay != 0 {
+		s.HookDelay = other.HookDelay
+	}
+	if other.Font != "" {
+		s.Font = other.Font
+	}
+	s.Extra = mergeSections(s.Extra, other.Extra)
+	return s
+}
+
+// mergeSections merges INI keys by section, with other taking precedence.
+func mergeSections(base, other map[string]map[string]string) map[string]map[string]string {
+	if len(other) == 0 {
+		return base
+	}
+	merged := make(map[string]map[string]string)
+	for _, source := range []map[string]map[string]string{base, other} {
+		for section, keys := range source {
+			if merged[section] == nil {
+				merged[section] = make(map[string]string)
+			}
+			for key, value := range keys {
+				merged[section][key] = value
+			}
+		}
+	}
+	return merged
+}
+
+// profilePath returns the path of the user's profile.
+func profilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, GbeDir, ProfileFileName), nil
+}
+
+// readProfile reads the stored profile, without defaults. A missing profile
+// yields an empty one.
+func readProfile(path string) (*Profile, error) {
+	profile := &Profile{}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, profile); err != nil {
+			return nil, fmt.Errorf("failed to decode profile: %w", err)
+		}
+	}
+	return profile, nil
+}
+
+// LoadProfile reads the user's profile. A missing profile yields defaults.
+// The STEAM_WEB_API_KEY environment variable overrides the stored key.
+func LoadProfile() (*Profile, error) {
+	path, err := profilePath()
+	if err != nil {
+		return nil, err
+	}
+	profile, err := readProfile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(profile.MetadataProviders) == 0 {
+		profile.MetadataProviders = DefaultMetadataProviders
+	}
+	if key := os.Getenv("STEAM_WEB_API_KEY"); key != "" {
+		profile.WebAPIKey = key
+	}
+	return profile, nil
+}
+
+// UpdateProfile applies update to the stored profile and saves it. Defaults
+// and environment overrides are not written back.
+func UpdateProfile(update func(*Profile)) error {
+	path, err := profilePath()
+	if err != nil {
+		return err
+	}
+	profile, err := readProfile(path)
+	if err != nil {
+		return err
+	}
+	update(profile)
+
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	return nil
 }
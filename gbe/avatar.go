@@ -0,0 +1,244 @@
+package gbe
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"gbe_fork_helper/config"
+)
+
+const (
+	// AvatarFileName is the account avatar the emulator loads from its settings.
+	AvatarFileName = "account_avatar.png"
+	// maxAvatarSide bounds the images accepted as avatars, to keep decoding cheap.
+	maxAvatarSide = 8192
+)
+
+// avatarSizes are Steam's avatar sizes with their file names: the full
+// avatar the emulator loads, then the medium and small ones.
+var avatarSizes = []struct {
+	size int
+	name string
+}{
+	{184, AvatarFileName},
+	{64, "account_avatar_medium.png"},
+	{32, "account_avatar_small.png"},
+}
+
+// AvatarOptions holds the optional settings for SetAvatar.
+type AvatarOptions struct {
+	// Game installs the avatar into the steam_settings of the game in the
+	// current directory instead of the emulator's global settings.
+	Game bool
+}
+
+// globalSettingsPath returns the emulator's global settings directory,
+// which every game falls back to.
+func globalSettingsPath() (string, error) {
+	if runtime.GOOS == "windows" {
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, "GSE Saves", "settings"), nil
+		}
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(dataHome, "GSE Saves", "settings"), nil
+}
+
+// prefixSettingsPaths returns the emulator's global settings directories
+// inside the Wine prefix of a Windows game, where %APPDATA% points for each
+// user of the prefix. A prefix the game has not run in yet has none.
+func prefixSettingsPaths(manifest *Manifest) []string {
+	if runtime.GOOS == "windows" || !strings.HasPrefix(manifest.Platform, "win") {
+		return nil
+	}
+	prefix, err := winePrefix(manifest.Wine, manifest.AppID)
+	if err != nil {
+		return nil
+	}
+	roaming, _ := filepath.Glob(filepath.Join(prefix, "drive_c", "users", "*", "AppData", "Roaming"))
+	var dirs []string
+	for _, dir := range roaming {
+		if filepath.Base(filepath.Dir(filepath.Dir(dir))) == "Public" {
+			continue
+		}
+		dirs = append(dirs, filepath.Join(dir, "GSE Saves", "settings"))
+	}
+	return dirs
+}
+
+// loadAvatar decodes a PNG, JPEG or GIF image to use as avatar.
+func loadAvatar(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer f.Close()
+
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a PNG, JPEG or GIF image: %w", path, err)
+	}
+	if cfg.Width > maxAvatarSide || cfg.Height > maxAvatarSide {
+		return nil, fmt.Errorf("image is too large: %dx%d, at most %dx%d", cfg.Width, cfg.Height, maxAvatarSide, maxAvatarSide)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s image: %w", format, err)
+	}
+
+	side := min(cfg.Width, cfg.Height)
+	if cfg.Width != cfg.Height {
+		log.Printf("WARN: The image is %dx%d. Using its centered %dx%d square.", cfg.Width, cfg.Height, side, side)
+	}
+	if side < avatarSizes[0].size {
+		log.Printf("WARN: The image is smaller than %d px and will be upscaled.", avatarSizes[0].size)
+	}
+	return img, nil
+}
+
+// resizeSquare crops the centered square of an image and resizes it to
+// size x size, averaging the source pixels covered by each target pixel.
+func resizeSquare(src image.Image, size int) *image.NRGBA {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		sy0, sy1 := y0+y*side/size, y0+(y+1)*side/size
+		sy1 = max(sy1, sy0+1)
+		for x := 0; x < size; x++ {
+			sx0, sx1 := x0+x*side/size, x0+(x+1)*side/size
+			sx1 = max(sx1, sx0+1)
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(bl / n), uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// writeAvatar writes the avatar in every size into dir.
+func writeAvatar(img image.Image, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create '%s': %w", dir, err)
+	}
+	for _, s := range avatarSizes {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, resizeSquare(img, s.size)); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", s.name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, s.name), buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", s.name, err)
+		}
+	}
+	log.Printf("INFO: Wrote the account avatar to %s", dir)
+	return nil
+}
+
+// SetAvatar validates an image and installs it as the account avatar, in
+// Steam's 184, 64 and 32 px sizes. By default it goes into the emulator's
+// global settings, and the Wine prefixes of known Windows games, and
+// enable_account_avatar is turned on in the profile and every known game.
+// With opts.Game it goes into the steam_settings of the
+// game in the current directory only, which takes precedence.
+func SetAvatar(imagePath string, opts AvatarOptions) error {
+	img, err := loadAvatar(imagePath)
+	if err != nil {
+		return err
+	}
+	enabled := true
+
+	if opts.Game {
+		manifest, err := LoadManifest(".")
+		if err != nil {
+			return err
+		}
+		libraryPaths := manifest.libraryPaths(".")
+		if len(libraryPaths) == 0 {
+			return fmt.Errorf("no emulator files found for the current directory. Run apply first")
+		}
+		manifest.Main.EnableAccountAvatar = &enabled
+		if err := manifest.Save("."); err != nil {
+			return err
+		}
+		settings := mainSettings(manifest, loadProfile())
+		for _, libraryPath := range libraryPaths {
+			if err := writeAvatar(img, filepath.Join(libraryPath, "steam_settings")); err != nil {
+				return err
+			}
+			if err := writeMainConfig(libraryPath, settings); err != nil {
+				return err
+			}
+		}
+		log.Printf("SUCCESS: Set the account avatar of the game in the current directory.")
+		return nil
+	}
+
+	dir, err := globalSettingsPath()
+	if err != nil {
+		return err
+	}
+	if err := writeAvatar(img, dir); err != nil {
+		return err
+	}
+	if err := config.UpdateProfile(func(p *config.Profile) {
+		p.Main.EnableAccountAvatar = &enabled
+	}); err != nil {
+		return err
+	}
+
+	games, err := KnownGames()
+	if err != nil {
+		return err
+	}
+	profile := loadProfile()
+	for _, root := range games {
+		manifest, err := LoadManifest(root)
+		if err != nil {
+			log.Printf("WARN: %s: %v", root, err)
+			continue
+		}
+		settings := mainSettings(manifest, profile)
+		for _, libraryPath := range manifest.libraryPaths(root) {
+			if err := writeMainConfig(libraryPath, settings); err != nil {
+				log.Printf("ERROR: Failed to write emulator settings in %s: %v", libraryPath, err)
+			}
+		}
+		// Windows builds run through Wine or Proton read %APPDATA% inside their prefix
+		for _, dir := range prefixSettingsPaths(manifest) {
+			if err := writeAvatar(img, dir); err != nil {
+				log.Printf("ERROR: Failed to write the account avatar in %s: %v", dir, err)
+			}
+		}
+	}
+	log.Printf("SUCCESS: Set the global account avatar and updated the settings of %d game(s).", len(games))
+	return nil
+}
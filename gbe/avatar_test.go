@@ -0,0 +1,120 @@
+package gbe
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadAvatar(t *testing.T) {
+	dir := t.TempDir()
+
+	// Test a JPEG image
+	imagePath := filepath.Join(dir, "avatar.jpg")
+	f, err := os.Create(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(f, image.NewRGBA(image.Rect(0, 0, 300, 200)), nil); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	img, err := loadAvatar(imagePath)
+	if err != nil {
+		t.Fatalf("loadAvatar failed: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 300 || b.Dy() != 200 {
+		t.Errorf("Expected a 300x200 image, got %dx%d", b.Dx(), b.Dy())
+	}
+
+	// Test a file that is not an image
+	notImage := filepath.Join(dir, "avatar.png")
+	if err := os.WriteFile(notImage, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadAvatar(notImage); err == nil {
+		t.Fatalf("loadAvatar was expected to fail for a file that is not an image but succeeded")
+	}
+
+	// Test a missing file
+	if _, err := loadAvatar(filepath.Join(dir, "missing.png")); err == nil {
+		t.Fatalf("loadAvatar was expected to fail for a missing file but succeeded")
+	}
+}
+
+func TestWriteAvatar(t *testing.T) {
+	// A 300x200 image, red on the left half and blue on the right half
+	src := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= 150 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+	dir := filepath.Join(t.TempDir(), "settings")
+	if err := writeAvatar(src, dir); err != nil {
+		t.Fatalf("writeAvatar failed: %v", err)
+	}
+
+	for _, s := range avatarSizes {
+		f, err := os.Open(filepath.Join(dir, s.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		avatar, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("Decoding %s failed: %v", s.name, err)
+		}
+
+		// Test the size of each file
+		if b := avatar.Bounds(); b.Dx() != s.size || b.Dy() != s.size {
+			t.Errorf("Expected %s to be %dx%d, got %dx%d", s.name, s.size, s.size, b.Dx(), b.Dy())
+		}
+
+		// Test that the centered square is kept, red then blue
+		if r, _, _, _ := avatar.At(1, s.size/2).RGBA(); r>>8 < 200 {
+			t.Errorf("Expected the left edge of %s to be red, got red %d", s.name, r>>8)
+		}
+		if _, _, b, _ := avatar.At(s.size-2, s.size/2).RGBA(); b>>8 < 200 {
+			t.Errorf("Expected the right edge of %s to be blue, got blue %d", s.name, b>>8)
+		}
+	}
+}
+
+func TestPrefixSettingsPaths(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	compatdata := t.TempDir()
+	for _, user := range []string{"steamuser", "Public"} {
+		if err := os.MkdirAll(filepath.Join(compatdata, "pfx", "drive_c", "users", user, "AppData", "Roaming"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Test a Windows game run through Proton, skipping the Public user
+	manifest := &Manifest{AppID: "480", Platform: "win64", Wine: WineConfig{Proton: "Proton 9.0", Prefix: compatdata}}
+	expected := []string{filepath.Join(compatdata, "pfx", "drive_c", "users", "steamuser", "AppData", "Roaming", "GSE Saves", "settings")}
+	if got := prefixSettingsPaths(manifest); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Test a prefix the game has not run in yet
+	manifest.Wine = WineConfig{Wine: "wine64", Prefix: t.TempDir()}
+	if got := prefixSettingsPaths(manifest); len(got) != 0 {
+		t.Errorf("Expected no directories for an empty prefix, got %v", got)
+	}
+
+	// Test a Linux game
+	manifest = &Manifest{AppID: "480", Platform: "linux", Wine: WineConfig{Prefix: compatdata}}
+	if got := prefixSettingsPaths(manifest); len(got) != 0 {
+		t.Errorf("Expected no directories for a Linux game, got %v", got)
+	}
+}
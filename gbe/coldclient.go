@@ -0,0 +1,166 @@
+package gbe
+
+import (
+	"debug/pe"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/util"
+)
+
+// LoaderConfigFileName is the configuration file read by the steamclient loader.
+const LoaderConfigFileName = "ColdClientLoader.ini"
+
+// exePlatform detects whether a Windows executable is 32 or 64 bit.
+func exePlatform(exe string) (string, error) {
+	f, err := pe.Open(exe)
+	if err != nil {
+		return "", fmt.Errorf("failed to read '%s' as a Windows executable: %w", exe, err)
+	}
+	defer f.Close()
+
+	switch f.FileHeader.Machine {
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return "win64", nil
+	case pe.IMAGE_FILE_MACHINE_I386:
+		return "win32", nil
+	default:
+		return "", fmt.Errorf("unsupported machine type 0x%x in '%s'", f.FileHeader.Machine, exe)
+	}
+}
+
+// copyLoaderFiles copies the steamclient loader files from srcDir into
+// destDir, backing up files that differ and skipping identical ones.
+func copyLoaderFiles(srcDir, destDir string) error {
+	return filepath.WalkDir(srcDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(destDir, rel)
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0755)
+		}
+		if d.Name() == LoaderConfigFileName {
+			return nil
+		}
+		if destHash, err := util.GetHash(dest); err == nil {
+			if srcHash, err := util.GetHash(path); err == nil && srcHash == destHash {
+				return nil
+			}
+		}
+		return util.BackupAndReplace(path, dest)
+	})
+}
+
+// gameRelPath returns path relative to the game directory root, rejecting
+// paths outside of it.
+func gameRelPath(root, path string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("'%s' is outside the game directory '%s'. Run the command from the game directory", path, absRoot)
+	}
+	return rel, nil
+}
+
+// writeLoaderConfig writes ColdClientLoader.ini for launching exe from loaderDir.
+func writeLoaderConfig(loaderDir, exe, appID string) error {
+	exeRel, err := filepath.Rel(loaderDir, exe)
+	if err != nil {
+		return err
+	}
+	runDir, err := filepath.Rel(loaderDir, filepath.Dir(exe))
+	if err != nil {
+		return err
+	}
+	injectDir := ""
+	if _, err := os.Stat(filepath.Join(loaderDir, "extra_dlls")); err == nil {
+		injectDir = "extra_dlls"
+	}
+
+	var content strings.Builder
+	content.WriteString("[SteamClient]\n")
+	content.WriteString(fmt.Sprintf("Exe=%s\n", exeRel))
+	content.WriteString(fmt.Sprintf("ExeRunDir=%s\n", runDir))
+	content.WriteString("ExeCommandLine=\n")
+	content.WriteString(fmt.Sprintf("AppId=%s\n", appID))
+	content.WriteString("SteamClientDll=steamclient.dll\n")
+	content.WriteString("SteamClient64Dll=steamclient64.dll\n")
+	content.WriteString("\n[Injection]\n")
+	content.WriteString("IgnoreLoaderArchDifference=0\n")
+	content.WriteString(fmt.Sprintf("DllsToInjectFolder=%s\n", injectDir))
+
+	configPath := filepath.Join(loaderDir, LoaderConfigFileName)
+	if err := os.WriteFile(configPath, []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", LoaderConfigFileName, err)
+	}
+	log.Printf("INFO: Wrote loader configuration to %s", configPath)
+	return nil
+}
+
+// SetupColdClient sets up the steamclient loader (ColdClientLoader) next to a
+// Windows game executable, for games that need the steamclient emulated
+// instead of steam_api replaced. It is run from the game directory, like ApplyGBE.
+func SetupColdClient(exe, appID string) error {
+	exe, err := gameRelPath(".", exe)
+	if err != nil {
+		return err
+	}
+	platform, err := exePlatform(exe)
+	if err != nil {
+		return err
+	}
+	platformCfg := config.PlatformConfig[platform]
+	log.Printf("INFO: Detected %s executable '%s'.", platform, exe)
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	loaderSource := filepath.Join(homeDir, config.GbeDir, platformCfg.LoaderDir)
+	if _, err := os.Stat(filepath.Join(loaderSource, platformCfg.Loader)); err != nil {
+		return fmt.Errorf("loader not found in '%s': %w", loaderSource, err)
+	}
+
+	loaderDir := filepath.Dir(exe)
+	if err := copyLoaderFiles(loaderSource, loaderDir); err != nil {
+		return fmt.Errorf("failed to copy loader files: %w", err)
+	}
+	if err := writeLoaderConfig(loaderDir, exe, appID); err != nil {
+		return err
+	}
+
+	manifest, err := LoadManifest(".")
+	if err != nil {
+		return err
+	}
+	configureSettings(appID, []string{loaderDir}, manifest, loadProfile())
+	manifest.AppID = appID
+	manifest.Platform = platform
+	manifest.Exe = filepath.ToSlash(exe)
+	manifest.Loader = filepath.ToSlash(filepath.Join(loaderDir, platformCfg.Loader))
+	if err := manifest.Save("."); err != nil {
+		log.Printf("WARN: %v", err)
+	}
+	if err := registerGame("."); err != nil {
+		log.Printf("WARN: Failed to register game: %v", err)
+	}
+
+	log.Printf("SUCCESS: ColdClientLoader set up. Launch the game with '%s'.", manifest.Loader)
+	return nil
+}
@@ -0,0 +1,51 @@
+package gbe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteLoaderConfig(t *testing.T) {
+	loaderDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(loaderDir, "extra_dlls"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(loaderDir, "bin", "game.exe")
+
+	if err := writeLoaderConfig(loaderDir, exe, "480"); err != nil {
+		t.Fatalf("writeLoaderConfig failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(loaderDir, LoaderConfigFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[SteamClient]\n" +
+		"Exe=" + filepath.Join("bin", "game.exe") + "\n" +
+		"ExeRunDir=bin\n" +
+		"ExeCommandLine=\n" +
+		"AppId=480\n" +
+		"SteamClientDll=steamclient.dll\n" +
+		"SteamClient64Dll=steamclient64.dll\n" +
+		"\n[Injection]\n" +
+		"IgnoreLoaderArchDifference=0\n" +
+		"DllsToInjectFolder=extra_dlls\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+}
+
+func TestGameRelPath(t *testing.T) {
+	root := t.TempDir()
+	rel, err := gameRelPath(root, filepath.Join(root, "bin", "game.exe"))
+	if err != nil {
+		t.Fatalf("gameRelPath failed: %v", err)
+	}
+	if expected := filepath.Join("bin", "game.exe"); rel != expected {
+		t.Errorf("Expected %q, got %q", expected, rel)
+	}
+
+	if _, err := gameRelPath(root, filepath.Join(filepath.Dir(root), "other", "game.exe")); err == nil {
+		t.Errorf("gameRelPath was expected to fail for a path outside the game but succeeded")
+	}
+}
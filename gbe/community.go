@@ -0,0 +1,88 @@
+package gbe
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/steam"
+)
+
+const (
+	// LeaderboardsFileName defines the game's leaderboards in steam_settings.
+	LeaderboardsFileName = "leaderboards.txt"
+	// GroupsFileName lists the IDs of the groups the user is subscribed to.
+	GroupsFileName = "subscribed_groups.txt"
+	// ClansFileName describes the subscribed groups that are clans.
+	ClansFileName = "subscribed_groups_clans.txt"
+)
+
+// writeSettingsFile writes a file into the steam_settings next to a steam_api file.
+func writeSettingsFile(libraryPath, name, content string) error {
+	settingsDir := filepath.Join(libraryPath, "steam_settings")
+	if err := os.MkdirAll(settingsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create steam_settings directory: %w", err)
+	}
+	path := filepath.Join(settingsDir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	log.Printf("INFO: Wrote %s", path)
+	return nil
+}
+
+// removeSettingsFile removes a file from the steam_settings next to a
+// steam_api file. A missing file is not an error.
+func removeSettingsFile(libraryPath, name string) error {
+	path := filepath.Join(libraryPath, "steam_settings", name)
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove %s: %w", name, err)
+	}
+	log.Printf("INFO: Removed %s", path)
+	return nil
+}
+
+// writeLeaderboards writes leaderboards.txt, one name=sort method=display
+// type line per leaderboard. Without leaderboards, the file is removed.
+func writeLeaderboards(libraryPath string, leaderboards []steam.Leaderboard) error {
+	if len(leaderboards) == 0 {
+		return removeSettingsFile(libraryPath, LeaderboardsFileName)
+	}
+	var content strings.Builder
+	for _, lb := range leaderboards {
+		content.WriteString(fmt.Sprintf("%s=%d=%d\n", lb.Name, lb.SortMethod, lb.DisplayType))
+	}
+	return writeSettingsFile(libraryPath, LeaderboardsFileName, content.String())
+}
+
+// writeGroups writes subscribed_groups.txt with the ID of every group and
+// subscribed_groups_clans.txt with the ID, name and tag of the groups that
+// have a name. Files left without groups are removed.
+func writeGroups(libraryPath string, groups []config.Group) error {
+	if len(groups) == 0 {
+		if err := removeSettingsFile(libraryPath, GroupsFileName); err != nil {
+			return err
+		}
+		return removeSettingsFile(libraryPath, ClansFileName)
+	}
+	var ids, clans strings.Builder
+	for _, group := range groups {
+		ids.WriteString(group.ID + "\n")
+		if group.Name != "" {
+			clans.WriteString(fmt.Sprintf("%s\t%s\t%s\n", group.ID, group.Name, group.Tag))
+		}
+	}
+	if err := writeSettingsFile(libraryPath, GroupsFileName, ids.String()); err != nil {
+		return err
+	}
+	if clans.Len() == 0 {
+		return removeSettingsFile(libraryPath, ClansFileName)
+	}
+	return writeSettingsFile(libraryPath, ClansFileName, clans.String())
+}
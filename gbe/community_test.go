@@ -0,0 +1,107 @@
+package gbe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/steam"
+)
+
+func TestWriteLeaderboards(t *testing.T) {
+	libraryPath := t.TempDir()
+	path := filepath.Join(libraryPath, "steam_settings", LeaderboardsFileName)
+
+	// Test that nothing is written without leaderboards
+	if err := writeLeaderboards(libraryPath, nil); err != nil {
+		t.Fatalf("writeLeaderboards failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(libraryPath, "steam_settings")); !os.IsNotExist(err) {
+		t.Errorf("Expected no steam_settings without leaderboards, got %v", err)
+	}
+
+	// Test writing one line per leaderboard
+	leaderboards := []steam.Leaderboard{
+		{Name: "Feet Traveled", SortMethod: 2, DisplayType: 1},
+		{Name: "Best Time", SortMethod: 1, DisplayType: 3},
+	}
+	if err := writeLeaderboards(libraryPath, leaderboards); err != nil {
+		t.Fatalf("writeLeaderboards failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Feet Traveled=2=1\nBest Time=1=3\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	// Test that the file is removed once the game has no leaderboards
+	if err := writeLeaderboards(libraryPath, nil); err != nil {
+		t.Fatalf("writeLeaderboards failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", LeaderboardsFileName, err)
+	}
+}
+
+func TestWriteGroups(t *testing.T) {
+	libraryPath := t.TempDir()
+	settingsDir := filepath.Join(libraryPath, "steam_settings")
+	groups := []config.Group{
+		{ID: "103582791429521412", Name: "Valve", Tag: "VALVE"},
+		{ID: "103582791433980119"},
+	}
+
+	// Test that nothing is written without groups
+	if err := writeGroups(libraryPath, nil); err != nil {
+		t.Fatalf("writeGroups failed: %v", err)
+	}
+	if _, err := os.Stat(settingsDir); !os.IsNotExist(err) {
+		t.Errorf("Expected no steam_settings without groups, got %v", err)
+	}
+
+	// Test writing every group, and the named ones as clans
+	if err := writeGroups(libraryPath, groups); err != nil {
+		t.Fatalf("writeGroups failed: %v", err)
+	}
+	for name, expected := range map[string]string{
+		GroupsFileName: "103582791429521412\n103582791433980119\n",
+		ClansFileName:  "103582791429521412\tValve\tVALVE\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(settingsDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("Expected %s %q, got %q", name, expected, data)
+		}
+	}
+
+	// Test that the clans file is removed once no group has a name
+	if err := writeGroups(libraryPath, groups[1:]); err != nil {
+		t.Fatalf("writeGroups failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(settingsDir, GroupsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "103582791433980119\n"; string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+	if _, err := os.Stat(filepath.Join(settingsDir, ClansFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", ClansFileName, err)
+	}
+
+	// Test that both files are removed without groups
+	if err := writeGroups(libraryPath, nil); err != nil {
+		t.Fatalf("writeGroups failed: %v", err)
+	}
+	for _, name := range []string{GroupsFileName, ClansFileName} {
+		if _, err := os.Stat(filepath.Join(settingsDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+}
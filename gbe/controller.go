@@ -0,0 +1,117 @@
+package gbe
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"gbe_fork_helper/steam"
+)
+
+// controllerDir is the steam_settings directory holding the controller action sets.
+const controllerDir = "controller"
+
+// controllerConfig reads the action sets of a game from a Steam Input
+// configuration: the given file, or else the first usable one in the Steam install.
+func controllerConfig(appID, file string) ([]steam.ControllerActionSet, string, error) {
+	files := []string{file}
+	if file == "" {
+		found, err := steam.FindControllerConfigs(appID)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(found) == 0 {
+			return nil, "", fmt.Errorf("no controller configuration found for AppID %s. Give one with --file", appID)
+		}
+		files = found
+	}
+
+	for _, path := range files {
+		root, err := steam.ParseVDFFile(path)
+		if err != nil {
+			if file != "" {
+				return nil, "", err
+			}
+			continue
+		}
+		sets, err := steam.ParseControllerConfig(root)
+		if err == nil && len(sets) > 0 {
+			return sets, path, nil
+		}
+		if file != "" {
+			if err == nil {
+				err = fmt.Errorf("no game actions bound in '%s'", path)
+			}
+			return nil, "", err
+		}
+	}
+	return nil, "", fmt.Errorf("no controller configuration with game actions found for AppID %s", appID)
+}
+
+// writeControllerConfig writes one <action set>.txt file per action set to
+// steam_settings/controller next to a steam_api file and copies Steam's
+// controller glyphs into its glyphs directory.
+func writeControllerConfig(libraryPath string, sets []steam.ControllerActionSet) error {
+	dir := filepath.Join(libraryPath, "steam_settings", controllerDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create controller directory: %w", err)
+	}
+	// Remove the action sets of an earlier configuration
+	stale, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove old action set: %w", err)
+		}
+	}
+	for _, set := range sets {
+		path := filepath.Join(dir, set.Name+".txt")
+		if err := os.WriteFile(path, []byte(set.Text()), 0644); err != nil {
+			return fmt.Errorf("failed to write action set %s: %w", set.Name, err)
+		}
+	}
+	log.Printf("INFO: Wrote %d controller action set(s) to %s", len(sets), dir)
+
+	glyphs, err := steam.ControllerGlyphsDir()
+	if err != nil {
+		log.Printf("WARN: %v. Skipping glyphs.", err)
+		return nil
+	}
+	if err := syncDir(glyphs, filepath.Join(dir, "glyphs")); err != nil {
+		return fmt.Errorf("failed to copy controller glyphs: %w", err)
+	}
+	return nil
+}
+
+// ImportController converts the Steam Input configuration of the game in
+// the current directory into the emulator's controller action sets. The
+// configuration is file, or else found in the Steam install.
+func ImportController(file string) error {
+	manifest, err := LoadManifest(".")
+	if err != nil {
+		return err
+	}
+	if manifest.AppID == "" {
+		return fmt.Errorf("no AppID known for the current directory. Run apply first")
+	}
+	libraryPaths := manifest.libraryPaths(".")
+	if len(libraryPaths) == 0 {
+		return fmt.Errorf("no emulator files found for the current directory. Run apply first")
+	}
+
+	sets, source, err := controllerConfig(manifest.AppID, file)
+	if err != nil {
+		return err
+	}
+	log.Printf("INFO: Using controller configuration '%s'.", source)
+	for _, libraryPath := range libraryPaths {
+		if err := writeControllerConfig(libraryPath, sets); err != nil {
+			return err
+		}
+	}
+	log.Println("SUCCESS: Controller configuration imported.")
+	return nil
+}
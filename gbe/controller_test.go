@@ -0,0 +1,32 @@
+package gbe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gbe_fork_helper/steam"
+)
+
+func TestWriteControllerConfigReplacesActionSets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	libraryPath := t.TempDir()
+	dir := filepath.Join(libraryPath, "steam_settings", controllerDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "OldControls.txt"), []byte("Jump=A\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sets := []steam.ControllerActionSet{{Name: "InGameControls", Actions: []steam.ControllerAction{{Name: "Jump", Inputs: []string{"A"}}}}}
+	if err := writeControllerConfig(libraryPath, sets); err != nil {
+		t.Fatalf("writeControllerConfig failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "InGameControls.txt")); err != nil {
+		t.Errorf("Expected InGameControls.txt to be written, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "OldControls.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected OldControls.txt to be removed, got %v", err)
+	}
+}
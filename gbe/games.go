@@ -0,0 +1,130 @@
+package gbe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/steam"
+)
+
+// gamesFileName lists the game directories GBE was applied to, inside GbeDir.
+const gamesFileName = "games.json"
+
+// gamesPath returns the path of the game registry.
+func gamesPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, config.GbeDir, gamesFileName), nil
+}
+
+// readGames reads the game registry. A missing registry yields no games.
+func readGames(path string) ([]string, error) {
+	var games []string
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game registry: %w", err)
+	}
+	if err := json.Unmarshal(data, &games); err != nil {
+		return nil, fmt.Errorf("failed to decode game registry: %w", err)
+	}
+	return games, nil
+}
+
+// registerGame adds a game directory to the registry so that commands
+// working on every game can find it.
+func registerGame(root string) error {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	path, err := gamesPath()
+	if err != nil {
+		return err
+	}
+	games, err := readGames(path)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(games, root) {
+		return nil
+	}
+	games = append(games, root)
+	slices.Sort(games)
+
+	data, err := json.MarshalIndent(games, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write game registry: %w", err)
+	}
+	return nil
+}
+
+// KnownGames returns the directories of every game with a manifest: those in
+// the registry and the installed Steam games GBE was applied to before the
+// registry existed.
+func KnownGames() ([]string, error) {
+	path, err := gamesPath()
+	if err != nil {
+		return nil, err
+	}
+	registered, err := readGames(path)
+	if err != nil {
+		return nil, err
+	}
+	candidates := registered
+	if apps, err := steam.InstalledApps(); err == nil {
+		for _, app := range apps {
+			candidates = append(candidates, app.InstallPath())
+		}
+	}
+
+	var games []string
+	for _, root := range candidates {
+		if _, err := os.Stat(filepath.Join(root, ManifestFileName)); err != nil {
+			continue
+		}
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		if !slices.Contains(games, root) {
+			games = append(games, root)
+		}
+	}
+	slices.Sort(games)
+	return games, nil
+}
+
+// libraryPaths returns the directories of a game holding emulator files, each
+// with its own steam_settings: those of the replaced steam_api files and of
+// the ColdClientLoader.
+func (m *Manifest) libraryPaths(root string) []string {
+	var paths []string
+	add := func(rel string) {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	for target := range m.Targets {
+		add(filepath.Dir(filepath.FromSlash(target)))
+	}
+	if m.Loader != "" {
+		add(filepath.Dir(filepath.FromSlash(m.Loader)))
+	}
+	slices.Sort(paths)
+	return paths
+}
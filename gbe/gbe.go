@@ -10,11 +10,179 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 )
 
+// ApplyOptions holds the optional settings for ApplyGBE. Zero values fall
+// back to the options recorded in the game's manifest, then to defaults.
+type ApplyOptions struct {
+	// Flavour selects the build flavour: regular, experimental or debug.
+	Flavour string
+	// Wine selects how the prebuilt Windows interface generator is run on
+	// other hosts. It is saved to the manifest for the run command.
+	Wine WineConfig
+	// UnlockAll, when set, selects whether every DLC is reported as owned.
+	// Unset, the manifest's choice and then the profile's default apply.
+	UnlockAll *bool
+	// AppPaths maps DLC AppIDs to their install folders and is merged into
+	// the manifest's. An empty path removes the entry.
+	AppPaths map[string]string
+	// Main holds the game's configs.main.ini settings, merged into the
+	// manifest's. They override the profile's defaults.
+	Main config.MainSettings
+	// InstalledApps, when not nil, replaces the AppIDs the manifest adds to
+	// installed_app_ids.txt.
+	InstalledApps []string
+	// Template, when set, selects whether the steam_settings template is
+	// applied to the game.
+	Template *bool
+}
+
+// loadProfile loads the user's profile, falling back to defaults on errors.
+func loadProfile() *config.Profile {
+	profile, err := config.LoadProfile()
+	if err != nil {
+		log.Printf("WARN: %v. Using default settings.", err)
+		return &config.Profile{MetadataProviders: config.DefaultMetadataProviders}
+	}
+	return profile
+}
+
+// dlcOptions resolves the DLC settings of a game from its manifest, the
+// user's DLC overrides and the profile.
+func dlcOptions(appID string, manifest *Manifest, profile *config.Profile) steam.DLCOptions {
+	opts := steam.DLCOptions{AppPaths: manifest.AppPaths}
+	overrides, err := steam.LoadDLCOverrides(appID, ".")
+	if err != nil {
+		log.Printf("WARN: Failed to load DLC overrides: %v", err)
+	}
+	opts.Overrides = overrides
+
+	opts.UnlockAll = profile.UnlockAllDLCs
+	if manifest.UnlockAll != nil {
+		opts.UnlockAll = *manifest.UnlockAll
+	}
+	return opts
+}
+
+// generatorConfig locates the prebuilt interface generator and how to run it.
+type generatorConfig struct {
+	subdir, generator, appID string
+	wine                     WineConfig
+}
+
+// selectFlavour returns the build flavour to apply and its release
+// directory: the requested one, else the one recorded in the manifest, else
+// the default.
+func selectFlavour(platform, requested, recorded string) (string, string, error) {
+	flavour := requested
+	if flavour == "" {
+		flavour = recorded
+	}
+	if flavour == "" {
+		flavour = config.DefaultFlavour
+	}
+	flavours := config.PlatformConfig[platform].Flavours
+	flavourDir, ok := flavours[flavour]
+	if !ok {
+		var validFlavours []string
+		for f := range flavours {
+			validFlavours = append(validFlavours, f)
+		}
+		sort.Strings(validFlavours)
+		return "", "", fmt.Errorf("invalid flavour: '%s'. Valid flavours: %s", flavour, strings.Join(validFlavours, ", "))
+	}
+	return flavour, flavourDir, nil
+}
+
+// generateInterfaces returns the interface versions of the original
+// steam_api file. If the native scan finds nothing, for example in a packed
+// binary, the release's prebuilt generator is run instead, through Wine or
+// Proton for the Windows generator on other hosts.
+func generateInterfaces(original string, gen generatorConfig) ([]string, error) {
+	interfaces, err := FindInterfaces(original)
+	if err != nil {
+		return nil, err
+	}
+	if len(interfaces) > 0 {
+		log.Printf("INFO: Found %d interfaces in '%s'.", len(interfaces), original)
+		return interfaces, nil
+	}
+
+	log.Printf("WARN: No interfaces found in '%s'.", original)
+	if runtime.GOOS == "windows" && !strings.HasSuffix(gen.generator, ".exe") {
+		return nil, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	generatorPath := filepath.Join(homeDir, config.GbeDir, gen.subdir, "tools", "generate_interfaces", gen.generator)
+	if _, err := os.Stat(generatorPath); err != nil {
+		return nil, nil
+	}
+	originalPath, err := filepath.Abs(original)
+	if err != nil {
+		return nil, err
+	}
+	workDir, err := os.MkdirTemp("", "gbe_interfaces")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	log.Printf("INFO: Running generator '%s'...", gen.generator)
+	var cmd *exec.Cmd
+	if needsWine(generatorPath) {
+		if cmd, err = wineCommand(gen.wine, gen.appID, generatorPath, []string{originalPath}); err != nil {
+			return nil, err
+		}
+	} else {
+		if runtime.GOOS != "windows" {
+			if err := os.Chmod(generatorPath, 0755); err != nil {
+				log.Printf("WARN: Failed to set executable permissions on '%s': %v", generatorPath, err)
+			}
+		}
+		cmd = exec.Command(generatorPath, originalPath)
+	}
+	cmd.Dir = workDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("generator failed: %v\nOutput: %s", err, string(out))
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, InterfacesFileName))
+	if err != nil {
+		return nil, fmt.Errorf("generator did not write %s: %w", InterfacesFileName, err)
+	}
+	return strings.Fields(string(data)), nil
+}
+
+// originalInterfaces returns the interface versions of the steam_api file
+// that file replaced or is about to replace. The manifest cache is used when
+// file is still the emulator build recorded there; otherwise the original is
+// scanned, from its backup if file has already been replaced. Without a
+// manifest record, a backup is preferred too, as file may then be an emulator
+// build installed by hand or by an older release.
+func originalInterfaces(file, fileHash, sourceHash string, state TargetState, hasState bool, gen generatorConfig) ([]string, error) {
+	if hasState && fileHash == state.Hash && len(state.Interfaces) > 0 {
+		return state.Interfaces, nil
+	}
+	original := file
+	backup, err := util.OriginalBackup(file)
+	switch {
+	case fileHash == sourceHash:
+		if err != nil {
+			return nil, fmt.Errorf("original of '%s' is not available: %w", file, err)
+		}
+		original = backup
+	case !hasState && err == nil:
+		original = backup
+	}
+	return generateInterfaces(original, gen)
+}
+
 // applyGBE applies the GBE patch to a specified platform.
-func ApplyGBE(platform, appID string) error {
+func ApplyGBE(platform, appID string, opts ApplyOptions) error {
 	platformCfg, ok := config.PlatformConfig[platform]
 	if !ok {
 		var validPlatforms []string
@@ -24,11 +192,21 @@ func ApplyGBE(platform, appID string) error {
 		return fmt.Errorf("invalid platform: '%s'. Valid platforms: %s", platform, strings.Join(validPlatforms, ", "))
 	}
 
+	manifest, err := LoadManifest(".")
+	if err != nil {
+		return err
+	}
+	flavour, flavourDir, err := selectFlavour(platform, opts.Flavour, manifest.Flavour)
+	if err != nil {
+		return err
+	}
+	log.Printf("INFO: Applying the %s build.", flavour)
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return fmt.Errorf("failed to get user home directory: %w", err)
 	}
-	gbePath := filepath.Join(homeDir, config.GbeDir, platformCfg.Subdir, "experimental", "x"+platformCfg.Arch)
+	gbePath := filepath.Join(homeDir, config.GbeDir, flavourDir)
 
 	var targetFiles []string
 	walkErr := filepath.WalkDir(".", func(path string, d os.DirEntry, err error) error {
@@ -58,6 +236,40 @@ func ApplyGBE(platform, appID string) error {
 		return fmt.Errorf("failed to get hash of source file: %w", err)
 	}
 
+	manifest.Wine = manifest.Wine.Merge(opts.Wine)
+	if opts.UnlockAll != nil {
+		manifest.UnlockAll = opts.UnlockAll
+	}
+	for id, path := range opts.AppPaths {
+		if manifest.AppPaths == nil {
+			manifest.AppPaths = make(map[string]string)
+		}
+		if path == "" {
+			delete(manifest.AppPaths, id)
+		} else {
+			manifest.AppPaths[id] = path
+		}
+	}
+	manifest.Main = manifest.Main.Merge(opts.Main)
+	if opts.InstalledApps != nil {
+		manifest.InstalledApps = opts.InstalledApps
+	}
+	if opts.Template != nil {
+		manifest.Template = opts.Template
+	}
+	if len(manifest.Main.Broadcasts) == 0 {
+		// An empty list falls back to the profile's broadcasts
+		manifest.Main.Broadcasts = nil
+	}
+	gen := generatorConfig{
+		subdir:    platformCfg.Subdir,
+		generator: platformCfg.Generator,
+		appID:     appID,
+		wine:      manifest.Wine,
+	}
+	if manifest.Targets == nil {
+		manifest.Targets = make(map[string]TargetState)
+	}
 	for _, file := range targetFiles {
 		log.Printf("INFO: Found potential target: '%s'", file)
 
@@ -67,51 +279,60 @@ func ApplyGBE(platform, appID string) error {
 			continue
 		}
 
-		if targetHash == sourceHash {
-			log.Println("SUCCESS: File is already up-to-date. Skipping.")
-			continue
+		// Extract the interfaces before the original is replaced
+		key := filepath.ToSlash(file)
+		state, hasState := manifest.Targets[key]
+		interfaces, err := originalInterfaces(file, targetHash, sourceHash, state, hasState, gen)
+		if err != nil {
+			log.Printf("ERROR: Failed to extract interfaces: %v", err)
 		}
 
-		if err := util.BackupAndReplace(sourceFile, file); err != nil {
-			log.Printf("ERROR: Failed to replace file '%s': %v. Skipping.", file, err)
-			continue
-		}
+		if targetHash == sourceHash {
+			log.Println("SUCCESS: File is already up-to-date.")
+		} else {
+			if err := util.BackupAndReplace(sourceFile, file); err != nil {
+				log.Printf("ERROR: Failed to replace file '%s': %v. Skipping.", file, err)
+				continue
+			}
 
-		if platformCfg.Additional != "" {
-			additionalSource := filepath.Join(gbePath, platformCfg.Additional)
-			additionalDest := filepath.Join(filepath.Dir(file), platformCfg.Additional)
-			if _, err := os.Stat(additionalSource); err == nil {
-				if err := util.BackupAndReplace(additionalSource, additionalDest); err != nil {
-					log.Printf("WARN: Failed to replace additional file '%s': %v", additionalDest, err)
+			if platformCfg.Additional != "" {
+				additionalSource := filepath.Join(gbePath, platformCfg.Additional)
+				additionalDest := filepath.Join(filepath.Dir(file), platformCfg.Additional)
+				if _, err := os.Stat(additionalSource); err == nil {
+					if err := util.BackupAndReplace(additionalSource, additionalDest); err != nil {
+						log.Printf("WARN: Failed to replace additional file '%s': %v", additionalDest, err)
+					}
 				}
 			}
 		}
 
-		homeDir, err = os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get user home directory: %w", err)
-		}
-		generatorPath := filepath.Join(homeDir, config.GbeDir, platformCfg.Subdir, "tools", "generate_interfaces", platformCfg.Generator)
-		if _, err := os.Stat(generatorPath); err == nil {
-			log.Printf("INFO: Running generator '%s'...", platformCfg.Generator)
-			if runtime.GOOS != "windows" {
-				if err := os.Chmod(generatorPath, 0755); err != nil {
-					log.Printf("WARN: Failed to set executable permissions on '%s': %v", generatorPath, err)
-				}
-			}
-			cmd := exec.Command(generatorPath, filepath.Base(file))
-			cmd.Dir = filepath.Dir(file)
-			if out, err := cmd.CombinedOutput(); err != nil {
-				log.Printf("ERROR: Generator failed: %v\nOutput: %s", err, string(out))
+		if len(interfaces) == 0 {
+			interfaces = state.Interfaces
+		}
+		manifest.Targets[key] = TargetState{Hash: sourceHash, Interfaces: interfaces}
+		if len(interfaces) > 0 {
+			if err := WriteInterfaces(filepath.Dir(file), interfaces); err != nil {
+				log.Printf("ERROR: %v", err)
 			}
 		}
 	}
 
-	// After applying GBE, fetch and configure DLCs
+	// After applying GBE, fetch and configure DLCs and the other settings
+	var libraryPaths []string
 	for _, file := range targetFiles {
-		libraryPath := filepath.Dir(file)
-		if err := steam.FetchDLCs(appID, libraryPath); err != nil {
-			log.Printf("WARN: Failed to fetch and configure DLCs for AppID %s in %s: %v", appID, libraryPath, err)
+		libraryPaths = append(libraryPaths, filepath.Dir(file))
+	}
+	configureSettings(appID, libraryPaths, manifest, loadProfile())
+
+	if len(targetFiles) > 0 {
+		manifest.AppID = appID
+		manifest.Platform = platform
+		manifest.Flavour = flavour
+		if err := manifest.Save("."); err != nil {
+			log.Printf("WARN: %v", err)
+		}
+		if err := registerGame("."); err != nil {
+			log.Printf("WARN: Failed to register game: %v", err)
 		}
 	}
 
@@ -0,0 +1,28 @@
+package gbe
+
+import "testing"
+
+func TestSelectFlavour(t *testing.T) {
+	for _, test := range []struct {
+		platform, requested, recorded string
+		expectedFlavour, expectedDir  string
+	}{
+		{"linux", "", "", "experimental", "linux_release/experimental/x64"},
+		{"linux", "", "regular", "regular", "linux_release/regular/x64"},
+		{"win64", "debug", "regular", "debug", "win_debug/experimental/x64"},
+		{"win32", "regular", "", "regular", "win_release/regular/x32"},
+	} {
+		flavour, dir, err := selectFlavour(test.platform, test.requested, test.recorded)
+		if err != nil {
+			t.Fatalf("selectFlavour failed for %s: %v", test.platform, err)
+		}
+		if flavour != test.expectedFlavour || dir != test.expectedDir {
+			t.Errorf("Expected %s in %s, got %s in %s", test.expectedFlavour, test.expectedDir, flavour, dir)
+		}
+	}
+
+	// Test an unknown flavour
+	if _, _, err := selectFlavour("linux", "nightly", ""); err == nil {
+		t.Errorf("selectFlavour was expected to fail for an unknown flavour but succeeded")
+	}
+}
@@ -0,0 +1,344 @@
+package gbe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/steam"
+	"gbe_fork_helper/util"
+)
+
+const (
+	// AchievementsFileName defines the game's achievements in steam_settings.
+	AchievementsFileName = "achievements.json"
+	// StatsFileName defines the game's stats in steam_settings.
+	StatsFileName = "stats.json"
+	// ItemsFileName defines the game's inventory items in steam_settings.
+	ItemsFileName = "items.json"
+	// DepotsFileName lists the game's depots in steam_settings.
+	DepotsFileName = "depots.txt"
+	// LanguagesFileName lists the game's supported languages in steam_settings.
+	LanguagesFileName = "supported_languages.txt"
+	// achievementImagesDir holds the achievement icons, inside steam_settings.
+	achievementImagesDir = "achievement_images"
+)
+
+// GenerateSections lists the sections GenerateConfig can generate, in order.
+var GenerateSections = []string{"dlcs", "achievements", "images", "stats", "items", "depots", "languages", "leaderboards", "controller", "settings"}
+
+// GenerateOptions holds the optional settings for GenerateConfig.
+type GenerateOptions struct {
+	// Dir is the directory holding the game's steam_api file. By default the
+	// directories recorded in the manifest of the current directory are
+	// used, or else the current directory.
+	Dir string
+	// Sections limits the generated sections. By default all are generated.
+	Sections []string
+	// ControllerFile is the Steam Input configuration to convert instead of
+	// the one found in the Steam install.
+	ControllerFile string
+}
+
+// errSkipped marks a section that had nothing to generate.
+var errSkipped = errors.New("skipped")
+
+// generator holds the state shared by the sections of GenerateConfig.
+type generator struct {
+	appID        string
+	libraryPaths []string
+	manifest     *Manifest
+	profile      *config.Profile
+	provider     steam.MetadataProvider
+	opts         GenerateOptions
+	achievements []steam.Achievement
+}
+
+// writeJSON writes v as indented JSON into steam_settings in every library path.
+func (g *generator) writeJSON(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	for _, libraryPath := range g.libraryPaths {
+		if err := writeSettingsFile(libraryPath, name, string(data)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLines writes a file with one line per value into steam_settings in every library path.
+func (g *generator) writeLines(name string, lines []string) error {
+	for _, libraryPath := range g.libraryPaths {
+		if err := writeSettingsFile(libraryPath, name, strings.Join(lines, "\n")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// section generates one section, returning a short summary.
+func (g *generator) section(name string) (string, error) {
+	switch name {
+	case "dlcs":
+		opts := dlcOptions(g.appID, g.manifest, g.profile)
+		for _, libraryPath := range g.libraryPaths {
+			if err := steam.FetchDLCs(g.appID, libraryPath, opts); err != nil {
+				return "", err
+			}
+		}
+		return "steam_appid.txt, configs.app.ini", nil
+
+	case "achievements":
+		achievements, err := g.provider.Achievements(g.appID)
+		if err != nil {
+			return "", err
+		}
+		if len(achievements) == 0 {
+			return "", errSkipped
+		}
+		g.achievements = achievements
+		if err := g.writeJSON(AchievementsFileName, achievements); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d achievement(s)", len(achievements)), nil
+
+	case "images":
+		if len(g.achievements) == 0 {
+			// Without the achievements section, use the achievements written earlier
+			data, err := os.ReadFile(filepath.Join(g.libraryPaths[0], "steam_settings", AchievementsFileName))
+			if err == nil {
+				if err := json.Unmarshal(data, &g.achievements); err != nil {
+					return "", fmt.Errorf("failed to decode %s: %w", AchievementsFileName, err)
+				}
+			} else if !os.IsNotExist(err) {
+				return "", fmt.Errorf("failed to read %s: %w", AchievementsFileName, err)
+			}
+		}
+		if len(g.achievements) == 0 {
+			return "", fmt.Errorf("no achievements to download icons for: %w", errSkipped)
+		}
+		downloaded, err := g.achievementImages()
+		if err != nil {
+			return "", err
+		}
+		if err := g.writeJSON(AchievementsFileName, g.achievements); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d icon(s)", downloaded), nil
+
+	case "stats":
+		stats, err := g.provider.Stats(g.appID)
+		if err != nil {
+			return "", err
+		}
+		if len(stats) == 0 {
+			return "", errSkipped
+		}
+		if err := g.writeJSON(StatsFileName, stats); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d stat(s)", len(stats)), nil
+
+	case "items":
+		items, err := g.provider.Items(g.appID)
+		if err != nil {
+			return "", err
+		}
+		if len(items) == 0 {
+			return "", errSkipped
+		}
+		byID := make(map[string]steam.InventoryItem, len(items))
+		for _, item := range items {
+			byID[item["itemdefid"]] = item
+		}
+		if err := g.writeJSON(ItemsFileName, byID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d item(s)", len(items)), nil
+
+	case "depots":
+		depots, err := g.provider.Depots(g.appID)
+		if err != nil {
+			return "", err
+		}
+		if len(depots) == 0 {
+			return "", errSkipped
+		}
+		return fmt.Sprintf("%d depot(s)", len(depots)), g.writeLines(DepotsFileName, depots)
+
+	case "languages":
+		languages, err := g.provider.Languages(g.appID)
+		if err != nil {
+			return "", err
+		}
+		if len(languages) == 0 {
+			return "", errSkipped
+		}
+		return fmt.Sprintf("%d language(s)", len(languages)), g.writeLines(LanguagesFileName, languages)
+
+	case "leaderboards":
+		leaderboards, err := g.provider.Leaderboards(g.appID)
+		if err != nil {
+			return "", err
+		}
+		for _, libraryPath := range g.libraryPaths {
+			if err := writeLeaderboards(libraryPath, leaderboards); err != nil {
+				return "", err
+			}
+		}
+		if len(leaderboards) == 0 {
+			return "", errSkipped
+		}
+		return fmt.Sprintf("%d leaderboard(s)", len(leaderboards)), nil
+
+	case "controller":
+		sets, source, err := controllerConfig(g.appID, g.opts.ControllerFile)
+		if err != nil {
+			if g.opts.ControllerFile == "" {
+				return "", fmt.Errorf("%v: %w", err, errSkipped)
+			}
+			return "", err
+		}
+		for _, libraryPath := range g.libraryPaths {
+			if err := writeControllerConfig(libraryPath, sets); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("%d action set(s) from %s", len(sets), filepath.Base(source)), nil
+
+	case "settings":
+		installed, err := installedAppIDs(g.appID, g.manifest, g.profile)
+		if err != nil {
+			log.Printf("WARN: %v. Every app is reported as installed.", err)
+		}
+		settings := mainSettings(g.manifest, g.profile)
+		for _, libraryPath := range g.libraryPaths {
+			for _, err := range []error{
+				writeMainConfig(libraryPath, settings),
+				writeOverlayConfig(libraryPath, g.profile.Overlay),
+				writeGroups(libraryPath, g.profile.SubscribedGroups),
+				writeInstalledApps(libraryPath, installed),
+			} {
+				if err != nil {
+					return "", err
+				}
+			}
+			if useTemplate(g.manifest) {
+				if err := applyTemplate(libraryPath); err != nil {
+					return "", err
+				}
+			}
+		}
+		return "main, overlay, groups, installed apps and template", nil
+	}
+	return "", fmt.Errorf("unknown section: '%s'", name)
+}
+
+// achievementImages downloads the achievement icons into
+// steam_settings/achievement_images and points the achievements at them.
+func (g *generator) achievementImages() (int, error) {
+	imagesDir := filepath.Join(g.libraryPaths[0], "steam_settings", achievementImagesDir)
+	if err := os.MkdirAll(imagesDir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create %s directory: %w", achievementImagesDir, err)
+	}
+
+	downloaded := 0
+	for i := range g.achievements {
+		for _, icon := range []*string{&g.achievements[i].Icon, &g.achievements[i].IconGray} {
+			if !strings.HasPrefix(*icon, "http://") && !strings.HasPrefix(*icon, "https://") {
+				continue
+			}
+			name := path.Base(*icon)
+			dest := filepath.Join(imagesDir, name)
+			if _, err := os.Stat(dest); err != nil {
+				if err := util.DownloadFile(*icon, dest, util.DownloadOptions{Retries: util.DefaultRetries}); err != nil {
+					log.Printf("WARN: Failed to download icon of achievement %s: %v", g.achievements[i].Name, err)
+					continue
+				}
+			}
+			*icon = path.Join(achievementImagesDir, name)
+			downloaded++
+		}
+	}
+
+	for _, libraryPath := range g.libraryPaths[1:] {
+		if err := syncDir(imagesDir, filepath.Join(libraryPath, "steam_settings", achievementImagesDir)); err != nil {
+			return 0, err
+		}
+	}
+	return downloaded, nil
+}
+
+// GenerateConfig generates the complete steam_settings of a game in one
+// pass: DLCs, achievements and their icons, stats, inventory items, depots,
+// languages, leaderboards, controller action sets and the emulator
+// settings. A report of every section is printed, and an error is returned
+// if any section failed.
+func GenerateConfig(appID string, opts GenerateOptions) error {
+	sections := GenerateSections
+	if len(opts.Sections) > 0 {
+		for _, s := range opts.Sections {
+			if !slices.Contains(GenerateSections, s) {
+				return fmt.Errorf("invalid section: '%s'. Valid sections: %s", s, strings.Join(GenerateSections, ", "))
+			}
+		}
+		sections = opts.Sections
+	}
+
+	manifest, err := LoadManifest(".")
+	if err != nil {
+		return err
+	}
+	libraryPaths := []string{"."}
+	if opts.Dir != "" {
+		libraryPaths = []string{opts.Dir}
+	} else if paths := manifest.libraryPaths("."); len(paths) > 0 && (manifest.AppID == "" || manifest.AppID == appID) {
+		libraryPaths = paths
+	}
+
+	g := &generator{
+		appID:        appID,
+		libraryPaths: libraryPaths,
+		manifest:     manifest,
+		profile:      loadProfile(),
+		provider:     steam.DefaultProvider(),
+		opts:         opts,
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	failed := 0
+	for _, name := range GenerateSections {
+		if !slices.Contains(sections, name) {
+			continue
+		}
+		log.Printf("INFO: Generating %s...", name)
+		summary, err := g.section(name)
+		switch {
+		case err == nil:
+			fmt.Fprintf(w, "%s\tOK\t%s\n", name, summary)
+		case errors.Is(err, errSkipped) || errors.Is(err, steam.ErrNoMetadata) || errors.Is(err, steam.ErrAppNotFound):
+			fmt.Fprintf(w, "%s\tSKIPPED\t%v\n", name, err)
+		default:
+			fmt.Fprintf(w, "%s\tFAILED\t%v\n", name, err)
+			failed++
+		}
+	}
+	fmt.Println()
+	w.Flush()
+
+	if failed > 0 {
+		return fmt.Errorf("%d section(s) failed", failed)
+	}
+	log.Printf("SUCCESS: Generated the configuration of AppID %s in %s.", appID, strings.Join(libraryPaths, ", "))
+	return nil
+}
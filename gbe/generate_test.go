@@ -0,0 +1,119 @@
+package gbe
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gbe_fork_helper/steam"
+)
+
+// fakeProvider answers stats, items and depots, and knows nothing else.
+type fakeProvider struct{}
+
+func (fakeProvider) ID() string                         { return "fake" }
+func (fakeProvider) AppName(string) (string, error)     { return "", steam.ErrNoMetadata }
+func (fakeProvider) DLCs(string) ([]string, error)      { return nil, steam.ErrNoMetadata }
+func (fakeProvider) Languages(string) ([]string, error) { return nil, steam.ErrNoMetadata }
+func (fakeProvider) Depots(string) ([]string, error)    { return []string{"481", "482"}, nil }
+func (fakeProvider) Achievements(string) ([]steam.Achievement, error) {
+	return nil, nil
+}
+func (fakeProvider) Leaderboards(string) ([]steam.Leaderboard, error) {
+	return nil, errors.New("connection refused")
+}
+func (fakeProvider) Stats(string) ([]steam.Stat, error) {
+	return []steam.Stat{{Name: "kills", Type: "int", Default: "0", Global: "0"}}, nil
+}
+func (fakeProvider) Items(string) ([]steam.InventoryItem, error) {
+	return []steam.InventoryItem{{"itemdefid": "100", "name": "Hat"}}, nil
+}
+
+func TestGeneratorSection(t *testing.T) {
+	libraryPath := t.TempDir()
+	settingsDir := filepath.Join(libraryPath, "steam_settings")
+	g := &generator{appID: "480", libraryPaths: []string{libraryPath}, provider: fakeProvider{}}
+
+	// Test the depots section
+	if _, err := g.section("depots"); err != nil {
+		t.Fatalf("depots section failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(settingsDir, DepotsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "481\n482\n" {
+		t.Errorf("Expected %q, got %q", "481\n482\n", data)
+	}
+
+	// Test that items are keyed by their ID
+	if _, err := g.section("items"); err != nil {
+		t.Fatalf("items section failed: %v", err)
+	}
+	data, err = os.ReadFile(filepath.Join(settingsDir, ItemsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items map[string]map[string]string
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+	if items["100"]["name"] != "Hat" {
+		t.Errorf("Expected item 100 named Hat, got %s", data)
+	}
+
+	// Test the stats section
+	if _, err := g.section("stats"); err != nil {
+		t.Fatalf("stats section failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(settingsDir, StatsFileName)); err != nil {
+		t.Errorf("Expected %s to be written, got %v", StatsFileName, err)
+	}
+
+	// Test that missing metadata skips a section without writing it
+	if _, err := g.section("languages"); !errors.Is(err, steam.ErrNoMetadata) {
+		t.Errorf("Expected ErrNoMetadata, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(settingsDir, LanguagesFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected no %s, got %v", LanguagesFileName, err)
+	}
+	for _, name := range []string{"achievements", "images"} {
+		if _, err := g.section(name); !errors.Is(err, errSkipped) {
+			t.Errorf("Expected %s to be skipped, got %v", name, err)
+		}
+	}
+
+	// Test that a failing provider is reported as a failure, not a skip, and keeps the old file
+	if err := writeLeaderboards(libraryPath, []steam.Leaderboard{{Name: "Best Time", SortMethod: 1, DisplayType: 3}}); err != nil {
+		t.Fatalf("writeLeaderboards failed: %v", err)
+	}
+	_, err = g.section("leaderboards")
+	if err == nil {
+		t.Fatalf("leaderboards section was expected to fail but succeeded")
+	}
+	if errors.Is(err, steam.ErrNoMetadata) || errors.Is(err, errSkipped) {
+		t.Errorf("Expected a failure, got a skip: %v", err)
+	}
+	data, err = os.ReadFile(filepath.Join(settingsDir, LeaderboardsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "Best Time=1=3\n" {
+		t.Errorf("Expected the old leaderboards to be kept, got %q", data)
+	}
+
+	// Test that images use an existing achievements.json
+	achievements := `[{"name": "WIN", "displayName": "Win", "icon": "achievement_images/win.jpg"}]`
+	if err := os.WriteFile(filepath.Join(settingsDir, AchievementsFileName), []byte(achievements), 0644); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := g.section("images")
+	if err != nil {
+		t.Fatalf("Expected the images section to use %s, got %v", AchievementsFileName, err)
+	}
+	if summary != "0 icon(s)" {
+		t.Errorf("Expected %q, got %q", "0 icon(s)", summary)
+	}
+}
@@ -0,0 +1,51 @@
+package gbe
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/steam"
+)
+
+// InstalledAppsFileName lists the AppIDs the emulator reports as installed.
+// Without it, every app is reported as installed.
+const InstalledAppsFileName = "installed_app_ids.txt"
+
+// installedAppIDs returns the AppIDs to report as installed for a game: the
+// apps installed in the Steam libraries, the game itself and the additions
+// of the profile and the manifest. Without a Steam install, the list is only
+// made when additions are configured, since a list of the game alone would
+// hide every other app.
+func installedAppIDs(appID string, manifest *Manifest, profile *config.Profile) ([]string, error) {
+	additions := append(slices.Clone(profile.InstalledApps), manifest.InstalledApps...)
+	ids := []string{appID}
+	apps, err := steam.InstalledApps()
+	if err != nil && len(additions) == 0 {
+		return nil, fmt.Errorf("failed to list installed apps: %w", err)
+	}
+	for _, app := range apps {
+		ids = append(ids, app.AppID)
+	}
+	ids = append(ids, additions...)
+
+	var unique []string
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" && !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	steam.SortAppIDs(unique)
+	return unique, nil
+}
+
+// writeInstalledApps writes installed_app_ids.txt, one AppID per line.
+// Without AppIDs, the file is removed so that every app is reported as
+// installed again.
+func writeInstalledApps(libraryPath string, ids []string) error {
+	if len(ids) == 0 {
+		return removeSettingsFile(libraryPath, InstalledAppsFileName)
+	}
+	return writeSettingsFile(libraryPath, InstalledAppsFileName, strings.Join(ids, "\n")+"\n")
+}
@@ -0,0 +1,75 @@
+package gbe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gbe_fork_helper/config"
+)
+
+func TestInstalledAppIDs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	manifest := &Manifest{InstalledApps: []string{"1000"}}
+	profile := &config.Profile{InstalledApps: []string{"20", "480"}}
+
+	// Test that only the additions are known without Steam
+	ids, err := installedAppIDs("480", manifest, profile)
+	if err != nil {
+		t.Fatalf("installedAppIDs failed: %v", err)
+	}
+	if expected := []string{"20", "480", "1000"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected %v, got %v", expected, ids)
+	}
+	if _, err := installedAppIDs("480", &Manifest{}, &config.Profile{}); err == nil {
+		t.Fatalf("installedAppIDs was expected to fail without Steam or additions but succeeded")
+	}
+
+	// Test that the apps installed in Steam are added
+
+	steamapps := filepath.Join(home, ".steam", "steam", "steamapps")
+	if err := os.MkdirAll(steamapps, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"228980", "70"} {
+		acf := fmt.Sprintf("\"AppState\"\n{\n\t\"appid\"\t\t\"%s\"\n\t\"name\"\t\t\"App %s\"\n\t\"installdir\"\t\t\"App%s\"\n}\n", id, id, id)
+		if err := os.WriteFile(filepath.Join(steamapps, "appmanifest_"+id+".acf"), []byte(acf), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids, err = installedAppIDs("480", manifest, profile)
+	if err != nil {
+		t.Fatalf("installedAppIDs failed: %v", err)
+	}
+	if expected := []string{"20", "70", "480", "1000", "228980"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Expected %v, got %v", expected, ids)
+	}
+}
+
+func TestWriteInstalledApps(t *testing.T) {
+	libraryPath := t.TempDir()
+	path := filepath.Join(libraryPath, "steam_settings", InstalledAppsFileName)
+
+	// Test writing one AppID per line
+	if err := writeInstalledApps(libraryPath, []string{"480", "1000"}); err != nil {
+		t.Fatalf("writeInstalledApps failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "480\n1000\n"; string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	// Test that the file is removed when no list can be made
+	if err := writeInstalledApps(libraryPath, nil); err != nil {
+		t.Fatalf("writeInstalledApps failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", InstalledAppsFileName, err)
+	}
+}
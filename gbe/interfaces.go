@@ -0,0 +1,84 @@
+package gbe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// InterfacesFileName is the file gbe_fork reads the game's interface versions from.
+const InterfacesFileName = "steam_interfaces.txt"
+
+// interfacePatterns matches the interface version strings embedded in
+// steam_api binaries, in the order they are written to steam_interfaces.txt.
+var interfacePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`SteamClient\d{3}`),
+	regexp.MustCompile(`SteamGameServer\d{3}`),
+	regexp.MustCompile(`SteamGameServerStats\d{3}`),
+	regexp.MustCompile(`SteamUser\d{3}`),
+	regexp.MustCompile(`SteamFriends\d{3}`),
+	regexp.MustCompile(`SteamUtils\d{3}`),
+	regexp.MustCompile(`SteamMatchMaking\d{3}`),
+	regexp.MustCompile(`SteamMatchMakingServers\d{3}`),
+	regexp.MustCompile(`STEAMUSERSTATS_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`STEAMAPPS_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`SteamNetworking\d{3}`),
+	regexp.MustCompile(`STEAMREMOTESTORAGE_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`STEAMSCREENSHOTS_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`STEAMHTTP_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`STEAMUNIFIEDMESSAGES_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`STEAMCONTROLLER_INTERFACE_VERSION\d{0,3}`),
+	regexp.MustCompile(`SteamController\d{3}`),
+	regexp.MustCompile(`STEAMUGC_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`STEAMAPPLIST_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`STEAMMUSIC_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`STEAMMUSICREMOTE_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`STEAMHTMLSURFACE_INTERFACE_VERSION_\d{3}`),
+	regexp.MustCompile(`STEAMINVENTORY_INTERFACE_V\d{3}`),
+	regexp.MustCompile(`STEAMVIDEO_INTERFACE_V\d{3}`),
+	regexp.MustCompile(`SteamMasterServerUpdater\d{3}`),
+	regexp.MustCompile(`STEAMPARENTALSETTINGS_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`SteamGameSearch\d{3}`),
+	regexp.MustCompile(`SteamParties\d{3}`),
+	regexp.MustCompile(`STEAMREMOTEPLAY_INTERFACE_VERSION\d{3}`),
+	regexp.MustCompile(`SteamInput\d{3}`),
+	regexp.MustCompile(`SteamNetworkingSockets\d{3}`),
+	regexp.MustCompile(`SteamNetworkingUtils\d{3}`),
+	regexp.MustCompile(`SteamNetworkingMessages\d{3}`),
+	regexp.MustCompile(`STEAMTIMELINE_INTERFACE_V\d{3}`),
+}
+
+// FindInterfaces scans a steam_api binary for the interface version strings
+// it was built with. The scan works on the raw bytes, so ELF and PE files are
+// handled identically regardless of the host OS.
+func FindInterfaces(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+
+	var interfaces []string
+	seen := make(map[string]bool)
+	for _, pattern := range interfacePatterns {
+		for _, match := range pattern.FindAll(data, -1) {
+			name := string(match)
+			if !seen[name] {
+				seen[name] = true
+				interfaces = append(interfaces, name)
+			}
+		}
+	}
+	return interfaces, nil
+}
+
+// WriteInterfaces writes steam_interfaces.txt to dir.
+func WriteInterfaces(dir string, interfaces []string) error {
+	path := filepath.Join(dir, InterfacesFileName)
+	content := strings.Join(interfaces, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", InterfacesFileName, err)
+	}
+	return nil
+}
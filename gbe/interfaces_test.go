@@ -0,0 +1,77 @@
+package gbe
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindInterfaces(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Interface strings are stored NUL-terminated among other data in both ELF and PE files
+	data := []byte("\x7fELF\x02\x01\x01\x00" +
+		"SteamUser021\x00garbage\x00SteamClient020\x00" +
+		"SteamGameServerStats001\x00SteamGameServer015\x00" +
+		"STEAMAPPS_INTERFACE_VERSION008\x00SteamUser021\x00" +
+		"SteamNetworkingSockets012\x00ISteamNetworking\x00")
+	binPath := filepath.Join(tmpDir, "libsteam_api.so")
+	if err := os.WriteFile(binPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	interfaces, err := FindInterfaces(binPath)
+	if err != nil {
+		t.Fatalf("FindInterfaces failed: %v", err)
+	}
+	expected := []string{
+		"SteamClient020",
+		"SteamGameServer015",
+		"SteamGameServerStats001",
+		"SteamUser021",
+		"STEAMAPPS_INTERFACE_VERSION008",
+		"SteamNetworkingSockets012",
+	}
+	if !reflect.DeepEqual(interfaces, expected) {
+		t.Errorf("Expected interfaces %q, got %q", expected, interfaces)
+	}
+
+	if err := WriteInterfaces(tmpDir, interfaces); err != nil {
+		t.Fatalf("WriteInterfaces failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tmpDir, InterfacesFileName))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", InterfacesFileName, err)
+	}
+	expectedContent := "SteamClient020\nSteamGameServer015\nSteamGameServerStats001\nSteamUser021\nSTEAMAPPS_INTERFACE_VERSION008\nSteamNetworkingSockets012\n"
+	if string(content) != expectedContent {
+		t.Errorf("Expected content %q, got %q", expectedContent, string(content))
+	}
+
+	// Test non-existent file
+	if _, err := FindInterfaces(filepath.Join(tmpDir, "missing.dll")); err == nil {
+		t.Fatalf("FindInterfaces was expected to fail for non-existent file but succeeded")
+	}
+}
+
+func TestOriginalInterfacesPrefersBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "libsteam_api.so")
+	// An emulator build installed without a manifest record
+	if err := os.WriteFile(file, []byte("SteamClient021\x00SteamUser023\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file+".20240101-120000.ORIGINAL", []byte("SteamClient017\x00SteamUser019\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	interfaces, err := originalInterfaces(file, "emulator", "newer", TargetState{}, false, generatorConfig{})
+	if err != nil {
+		t.Fatalf("originalInterfaces failed: %v", err)
+	}
+	expected := []string{"SteamClient017", "SteamUser019"}
+	if !reflect.DeepEqual(interfaces, expected) {
+		t.Errorf("Expected interfaces %q, got %q", expected, interfaces)
+	}
+}
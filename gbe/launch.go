@@ -0,0 +1,230 @@
+package gbe
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/steam"
+	"gbe_fork_helper/util"
+)
+
+// launchOptionsFileName stores the launch options replaced by SetSteamLaunch, inside GbeDir.
+const launchOptionsFileName = "launch_options.json"
+
+// SteamLaunchOptions holds the settings for SetSteamLaunch.
+type SteamLaunchOptions struct {
+	// Mode is "run" to launch through the run command, or "coldclient" to
+	// launch the ColdClientLoader set up for the game instead of its exe.
+	Mode string
+	// User is the Steam account ID whose settings are edited. Empty selects
+	// the most recent user.
+	User string
+	// Force edits localconfig.vdf even while Steam is running.
+	Force bool
+}
+
+// launchOptionValue builds the Steam launch option routing appID through the selected mode.
+func launchOptionValue(appID, mode string) (string, error) {
+	switch mode {
+	case "", "run":
+		self, err := os.Executable()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate gbe_fork_helper executable: %w", err)
+		}
+		return fmt.Sprintf(`"%s" run %s --wrap -- %%command%%`, self, appID), nil
+	case "coldclient":
+		if runtime.GOOS == "windows" {
+			return "", fmt.Errorf("coldclient launch options require a POSIX shell and are not supported on Windows")
+		}
+		app, err := steam.FindApp(appID)
+		if err != nil {
+			return "", err
+		}
+		manifest, err := LoadManifest(app.InstallPath())
+		if err != nil {
+			return "", err
+		}
+		if manifest.Loader == "" || manifest.Exe == "" {
+			return "", fmt.Errorf("no ColdClientLoader set up for AppID %s. Run the coldclient command first", appID)
+		}
+		exe, loader := filepath.Base(manifest.Exe), filepath.Base(manifest.Loader)
+		if strings.ContainsAny(exe+loader, `'"`) {
+			return "", fmt.Errorf("executable names containing quotes are not supported")
+		}
+		// Steam substitutes the full game command line for %command%; swap the game's exe for the loader
+		return fmt.Sprintf(`bash -c 'exec "${@/"%s"/"%s"}"' -- %%command%%`, exe, loader), nil
+	default:
+		return "", fmt.Errorf("invalid mode: '%s'. Valid modes: run, coldclient", mode)
+	}
+}
+
+// openLocalConfig locates and parses the selected user's localconfig.vdf.
+func openLocalConfig(user string, force bool) (string, *steam.KeyValue, error) {
+	if steam.IsRunning() {
+		if !force {
+			return "", nil, fmt.Errorf("Steam is running and would overwrite the change on exit. Close Steam first or pass --force")
+		}
+		log.Println("WARN: Steam is running. Changes may be overwritten when it exits.")
+	}
+
+	steamRoot, err := steam.FindSteamRoot()
+	if err != nil {
+		return "", nil, err
+	}
+	if user == "" {
+		users, err := steam.UserIDs(steamRoot)
+		if err != nil {
+			return "", nil, err
+		}
+		user = users[0]
+		if len(users) > 1 {
+			log.Printf("INFO: Several Steam users found. Using %s, select another with --user.", user)
+		}
+	}
+	path := steam.LocalConfigPath(steamRoot, user)
+	root, err := steam.ParseVDFFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, root, nil
+}
+
+// savedLaunchOptions loads the launch options replaced by SetSteamLaunch, keyed by "<localconfig path>:<appid>".
+func savedLaunchOptions() (string, map[string]string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	path := filepath.Join(homeDir, config.GbeDir, launchOptionsFileName)
+	saved := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &saved)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", nil, fmt.Errorf("failed to read saved launch options: %w", err)
+	}
+	return path, saved, nil
+}
+
+// writeLocalConfig backs up localconfig.vdf and writes the edited document.
+func writeLocalConfig(path string, root *steam.KeyValue) error {
+	backupPath := fmt.Sprintf("%s.%s.ORIGINAL", path, time.Now().Format("20060102-150405"))
+	// Keep an existing backup made within the same second, it holds the older content
+	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+		if err := util.CopyFile(path, backupPath); err != nil {
+			return fmt.Errorf("failed to back up '%s': %w", path, err)
+		}
+		log.Printf("INFO: Backed up '%s' to '%s'", path, backupPath)
+	}
+	if err := steam.WriteVDFFile(path, root); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	return nil
+}
+
+// SetSteamLaunch sets the Steam launch options of appID so that Steam starts
+// the game through the emulator. The previous launch options are kept for
+// RemoveSteamLaunch.
+func SetSteamLaunch(appID string, opts SteamLaunchOptions) error {
+	value, err := launchOptionValue(appID, opts.Mode)
+	if err != nil {
+		return err
+	}
+	path, root, err := openLocalConfig(opts.User, opts.Force)
+	if err != nil {
+		return err
+	}
+	previous, err := steam.GetLaunchOptions(root, appID)
+	if err != nil {
+		return err
+	}
+	if previous == value {
+		log.Println("SUCCESS: Launch options are already set.")
+		return nil
+	}
+
+	savedPath, saved, err := savedLaunchOptions()
+	if err != nil {
+		return err
+	}
+	key := path + ":" + appID
+	if _, ok := saved[key]; !ok {
+		saved[key] = previous
+	}
+
+	if err := steam.SetLaunchOptions(root, appID, value); err != nil {
+		return err
+	}
+	if err := writeLocalConfig(path, root); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(savedPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(savedPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to save previous launch options: %w", err)
+	}
+
+	log.Printf("SUCCESS: Set launch options for AppID %s to: %s", appID, value)
+	return nil
+}
+
+// RemoveSteamLaunch restores the launch options of appID replaced by SetSteamLaunch.
+func RemoveSteamLaunch(appID string, opts SteamLaunchOptions) error {
+	path, root, err := openLocalConfig(opts.User, opts.Force)
+	if err != nil {
+		return err
+	}
+	savedPath, saved, err := savedLaunchOptions()
+	if err != nil {
+		return err
+	}
+	key := path + ":" + appID
+	previous, ok := saved[key]
+	if !ok {
+		current, err := steam.GetLaunchOptions(root, appID)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(current, " run "+appID+" --wrap") && !strings.Contains(current, "${@/") {
+			log.Printf("SUCCESS: No launch options set by gbe_fork_helper for AppID %s.", appID)
+			return nil
+		}
+	}
+
+	if err := steam.SetLaunchOptions(root, appID, previous); err != nil {
+		return err
+	}
+	if err := writeLocalConfig(path, root); err != nil {
+		return err
+	}
+
+	delete(saved, key)
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(savedPath, data, 0644); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to update saved launch options: %w", err)
+	}
+
+	if previous == "" {
+		log.Printf("SUCCESS: Removed launch options for AppID %s.", appID)
+	} else {
+		log.Printf("SUCCESS: Restored launch options for AppID %s to: %s", appID, previous)
+	}
+	return nil
+}
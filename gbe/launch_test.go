@@ -0,0 +1,89 @@
+package gbe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gbe_fork_helper/steam"
+)
+
+func TestSteamLaunch(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	steamRoot := filepath.Join(home, ".steam", "steam")
+	if err := os.MkdirAll(filepath.Join(steamRoot, "steamapps"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := steam.LocalConfigPath(steamRoot, "1000")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	original := "\"UserLocalConfigStore\"\n" +
+		"{\n" +
+		"\t\"Software\"\n" +
+		"\t{\n" +
+		"\t\t\"Valve\"\n" +
+		"\t\t{\n" +
+		"\t\t\t\"Steam\"\n" +
+		"\t\t\t{\n" +
+		"\t\t\t\t\"apps\"\n" +
+		"\t\t\t\t{\n" +
+		"\t\t\t\t\t\"480\"\n" +
+		"\t\t\t\t\t{\n" +
+		"\t\t\t\t\t\t\"LaunchOptions\"\t\t\"PROTON_LOG=1 %command%\"\n" +
+		"\t\t\t\t\t\t\"InstallPath\"\t\t\"C:\\xbox\\\\Games\"\t\t[$WIN32]\n" +
+		"\t\t\t\t\t}\n" +
+		"\t\t\t\t}\n" +
+		"\t\t\t}\n" +
+		"\t\t}\n" +
+		"\t}\n" +
+		"}\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := SteamLaunchOptions{Force: true}
+
+	// Test setting the launch options for the only user
+	if err := SetSteamLaunch("480", opts); err != nil {
+		t.Fatalf("SetSteamLaunch failed: %v", err)
+	}
+	root, err := steam.ParseVDFFile(path)
+	if err != nil {
+		t.Fatalf("ParseVDFFile failed: %v", err)
+	}
+	value, _ := steam.GetLaunchOptions(root, "480")
+	if !strings.Contains(value, " run 480 --wrap -- %command%") {
+		t.Errorf("Expected launch options running the game, got %q", value)
+	}
+	backups, _ := filepath.Glob(path + ".*.ORIGINAL")
+	if len(backups) != 1 {
+		t.Errorf("Expected a backup of localconfig.vdf, got %v", backups)
+	}
+
+	// Test setting them again, keeping the first saved options
+	if err := SetSteamLaunch("480", opts); err != nil {
+		t.Fatalf("SetSteamLaunch failed: %v", err)
+	}
+
+	// Test that removing them restores the file
+	if err := RemoveSteamLaunch("480", opts); err != nil {
+		t.Fatalf("RemoveSteamLaunch failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != original {
+		t.Errorf("Expected the original localconfig.vdf.\nExpected:\n%s\nGot:\n%s", original, data)
+	}
+
+	// Test removing again when nothing is set
+	if err := RemoveSteamLaunch("480", opts); err != nil {
+		t.Fatalf("RemoveSteamLaunch failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Errorf("Expected localconfig.vdf to be unchanged, got:\n%s", data)
+	}
+}
@@ -0,0 +1,106 @@
+package gbe
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/util"
+)
+
+const (
+	// MainConfigFileName is the emulator's general settings file in steam_settings.
+	MainConfigFileName = "configs.main.ini"
+	// BroadcastsFileName lists the extra addresses the emulator broadcasts to.
+	BroadcastsFileName = "custom_broadcasts.txt"
+)
+
+// mainConfigKeys maps the typed settings to their configs.main.ini section and key.
+func mainConfigKeys(settings config.MainSettings) []struct {
+	section, key string
+	value        *bool
+} {
+	return []struct {
+		section, key string
+		value        *bool
+	}{
+		{"main::general", "enable_account_avatar", settings.EnableAccountAvatar},
+		{"main::general", "steam_deck", settings.SteamDeck},
+		{"main::connectivity", "offline", settings.Offline},
+		{"main::connectivity", "disable_networking", settings.DisableNetworking},
+		{"main::connectivity", "disable_lan_only", settings.DisableLANOnly},
+		{"main::misc", "achievements_bypass", settings.AchievementsBypass},
+	}
+}
+
+// mainSettings resolves the configs.main.ini settings of a game: the
+// profile's defaults overridden by the game's own.
+func mainSettings(manifest *Manifest, profile *config.Profile) config.MainSettings {
+	return profile.Main.Merge(manifest.Main)
+}
+
+// writeMainConfig writes the settings to configs.main.ini and
+// custom_broadcasts.txt in the steam_settings directory next to a steam_api
+// file. Keys the settings do not set are left as they are, and nothing is
+// written when no setting is made. Without broadcasts, custom_broadcasts.txt
+// is removed.
+func writeMainConfig(libraryPath string, settings config.MainSettings) error {
+	settingsDir := filepath.Join(libraryPath, "steam_settings")
+	iniPath := filepath.Join(settingsDir, MainConfigFileName)
+	ini, err := util.ReadINIFile(iniPath)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, option := range mainConfigKeys(settings) {
+		if option.value != nil {
+			ini.Set(option.section, option.key, boolValue(*option.value))
+			changed = true
+		}
+	}
+	if settings.ListenPort != 0 {
+		ini.Set("main::connectivity", "listen_port", strconv.Itoa(settings.ListenPort))
+		changed = true
+	}
+	for section, keys := range settings.Extra {
+		for key, value := range keys {
+			ini.Set(section, key, value)
+			changed = true
+		}
+	}
+	if changed {
+		if err := util.WriteINIFile(iniPath, ini); err != nil {
+			return err
+		}
+		log.Printf("INFO: Wrote emulator settings to %s", iniPath)
+	}
+
+	broadcastsPath := filepath.Join(settingsDir, BroadcastsFileName)
+	if len(settings.Broadcasts) == 0 {
+		if err := os.Remove(broadcastsPath); err == nil {
+			log.Printf("INFO: Removed %s", broadcastsPath)
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", BroadcastsFileName, err)
+		}
+		return nil
+	}
+	content := strings.Join(settings.Broadcasts, "\n") + "\n"
+	if err := os.WriteFile(broadcastsPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", BroadcastsFileName, err)
+	}
+	log.Printf("INFO: Wrote %d custom broadcast address(es) to %s", len(settings.Broadcasts), broadcastsPath)
+	return nil
+}
+
+// boolValue formats a boolean as the emulator's 1 or 0.
+func boolValue(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,69 @@
+package gbe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gbe_fork_helper/config"
+)
+
+func TestWriteMainConfig(t *testing.T) {
+	// Test that nothing is written without settings
+	libraryPath := t.TempDir()
+	if err := writeMainConfig(libraryPath, config.MainSettings{}); err != nil {
+		t.Fatalf("writeMainConfig failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(libraryPath, "steam_settings")); !os.IsNotExist(err) {
+		t.Errorf("Expected no steam_settings without settings, got %v", err)
+	}
+
+	// Test that the game's settings override the profile's, keeping unmanaged keys and comments
+	settingsDir := filepath.Join(libraryPath, "steam_settings")
+	if err := os.MkdirAll(settingsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := "[main::connectivity]\n# keep me\nlisten_port=1234\ndisable_source_query=1\n"
+	if err := os.WriteFile(filepath.Join(settingsDir, MainConfigFileName), []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	yes, no := true, false
+	profile := &config.Profile{Main: config.MainSettings{Offline: &yes, ListenPort: 47584, Broadcasts: []string{"10.0.0.1"}}}
+	manifest := &Manifest{Main: config.MainSettings{
+		Offline:        &no,
+		DisableLANOnly: &yes,
+		Broadcasts:     []string{"192.168.1.2", "192.168.1.3"},
+		Extra:          map[string]map[string]string{"main::misc": {"free_weekend": "1"}},
+	}}
+	if err := writeMainConfig(libraryPath, mainSettings(manifest, profile)); err != nil {
+		t.Fatalf("writeMainConfig failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(settingsDir, MainConfigFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[main::connectivity]\n# keep me\nlisten_port=47584\ndisable_source_query=1\noffline=0\ndisable_lan_only=1\n\n[main::misc]\nfree_weekend=1\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	// Test that the game's broadcasts replace the profile's
+	broadcasts, err := os.ReadFile(filepath.Join(settingsDir, BroadcastsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "192.168.1.2\n192.168.1.3\n"
+	if string(broadcasts) != expected {
+		t.Errorf("Expected %q, got %q", expected, broadcasts)
+	}
+
+	// Test that custom_broadcasts.txt is removed once no list is left
+	profile.Main.Broadcasts = nil
+	manifest.Main.Broadcasts = nil
+	if err := writeMainConfig(libraryPath, mainSettings(manifest, profile)); err != nil {
+		t.Fatalf("writeMainConfig failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(settingsDir, BroadcastsFileName)); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed, got %v", BroadcastsFileName, err)
+	}
+}
@@ -0,0 +1,77 @@
+package gbe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gbe_fork_helper/config"
+)
+
+// ManifestFileName is the per-game file recording how GBE was applied.
+const ManifestFileName = ".gbe_fork_helper.json"
+
+// Manifest records the options GBE was applied with in a game directory so
+// that re-applies and other commands can reuse them.
+type Manifest struct {
+	AppID    string `json:"appid"`
+	Platform string `json:"platform"`
+	Flavour  string `json:"flavour"`
+	// Exe is the game executable, relative to the game directory.
+	Exe string `json:"exe,omitempty"`
+	// Loader is the ColdClientLoader executable set up for the game, if any.
+	Loader string `json:"loader,omitempty"`
+	// Wine selects how Windows executables of the game are run on other hosts.
+	Wine WineConfig `json:"wine"`
+	// UnlockAll overrides the profile's unlock_all_dlcs when set.
+	UnlockAll *bool `json:"unlock_all,omitempty"`
+	// AppPaths maps DLC AppIDs to their install folders for configs.app.ini.
+	AppPaths map[string]string `json:"app_paths,omitempty"`
+	// Main holds the game's configs.main.ini settings, overriding the profile's.
+	Main config.MainSettings `json:"main"`
+	// InstalledApps lists AppIDs reported as installed on top of those
+	// installed in the Steam libraries and the profile's.
+	InstalledApps []string `json:"installed_apps,omitempty"`
+	// Template, when false, opts the game out of the steam_settings template.
+	Template *bool `json:"template,omitempty"`
+	// Targets maps each replaced file, relative to the game directory, to its state.
+	Targets map[string]TargetState `json:"targets,omitempty"`
+}
+
+// TargetState records a replaced steam_api file.
+type TargetState struct {
+	// Hash is the hash of the emulator file installed over the original.
+	Hash string `json:"hash"`
+	// Interfaces lists the interface versions of the original file.
+	Interfaces []string `json:"interfaces"`
+}
+
+// LoadManifest reads the manifest in a game directory. A missing manifest
+// yields an empty one.
+func LoadManifest(root string) (*Manifest, error) {
+	manifest := &Manifest{}
+	data, err := os.ReadFile(filepath.Join(root, ManifestFileName))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// Save writes the manifest to a game directory.
+func (m *Manifest) Save(root string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(root, ManifestFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package gbe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	root := t.TempDir()
+
+	// Test a missing manifest
+	manifest, err := LoadManifest(root)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if !reflect.DeepEqual(manifest, &Manifest{}) {
+		t.Errorf("Expected an empty manifest, got %+v", manifest)
+	}
+
+	// Test a save and load round trip
+	saved := &Manifest{AppID: "480", Platform: "linux", Flavour: "debug"}
+	if err := saved.Save(root); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadManifest(root)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, saved) {
+		t.Errorf("Expected %+v, got %+v", saved, loaded)
+	}
+}
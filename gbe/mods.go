@@ -0,0 +1,270 @@
+package gbe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gbe_fork_helper/steam"
+	"gbe_fork_helper/util"
+)
+
+const (
+	// ModsFileName is the emulator's workshop item metadata file in steam_settings.
+	ModsFileName = "mods.json"
+	// workshopItemURL is the community page of a workshop item.
+	workshopItemURL = "https://steamcommunity.com/sharedfiles/filedetails/?id=%s"
+)
+
+// ModsOptions holds the optional settings for ImportMods.
+type ModsOptions struct {
+	// Workshop imports every item Steam downloaded for the game.
+	Workshop bool
+	// Link records the path of each mod folder in mods.json instead of
+	// copying the folder into steam_settings/mods.
+	Link bool
+	// Offline skips fetching titles and preview images.
+	Offline bool
+	// Dir is the game directory. By default the current directory is used.
+	Dir string
+}
+
+// modSource is a mod folder to import.
+type modSource struct {
+	id, dir string
+}
+
+// modSources resolves the mod folders to import. Each source is a folder
+// named after its workshop item ID or given as <id>=<path>.
+func modSources(appID string, sources []string, opts ModsOptions) ([]modSource, error) {
+	var mods []modSource
+	if opts.Workshop {
+		contentDir, err := steam.WorkshopContentDir(appID)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(contentDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read workshop content: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && isDigits(entry.Name()) {
+				mods = append(mods, modSource{entry.Name(), filepath.Join(contentDir, entry.Name())})
+			}
+		}
+	}
+	for _, source := range sources {
+		id, dir, ok := strings.Cut(source, "=")
+		if !ok {
+			dir, id = source, filepath.Base(filepath.Clean(source))
+		}
+		if !isDigits(id) {
+			return nil, fmt.Errorf("no workshop item ID for '%s'. Name the folder after the ID or use <id>=<path>", source)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("mod folder not found: '%s'", dir)
+		}
+		mods = append(mods, modSource{id, dir})
+	}
+	return mods, nil
+}
+
+// readMods reads mods.json, keeping the keys this tool does not manage.
+func readMods(path string) (map[string]map[string]any, error) {
+	mods := make(map[string]map[string]any)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return mods, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ModsFileName, err)
+	}
+	// Keep large numbers such as owner SteamIDs exact
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&mods); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", ModsFileName, err)
+	}
+	return mods, nil
+}
+
+// dirSize returns the total size of the files in a directory.
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// downloadPreview downloads a workshop item's preview image into dir and
+// returns its file name, reusing an earlier download.
+func downloadPreview(previewURL, dir string) (string, error) {
+	name := path.Base(strings.TrimSuffix(strings.SplitN(previewURL, "?", 2)[0], "/"))
+	if path.Ext(name) == "" {
+		name = "preview.jpg"
+	}
+	dest := filepath.Join(dir, name)
+	if _, err := os.Stat(dest); err == nil {
+		return name, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if err := util.DownloadFile(previewURL, dest, util.DownloadOptions{Retries: util.DefaultRetries}); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ImportMods imports workshop mod folders into the steam_settings of the
+// game in the current directory, or opts.Dir, and describes them in
+// mods.json, so that the emulator reports them as subscribed workshop
+// items. Titles and preview images are fetched from the workshop unless
+// offline.
+func ImportMods(sources []string, opts ModsOptions) error {
+	root := opts.Dir
+	if root == "" {
+		root = "."
+	}
+	manifest, err := LoadManifest(root)
+	if err != nil {
+		return err
+	}
+	if manifest.AppID == "" {
+		return fmt.Errorf("no AppID known for the current directory. Run apply first")
+	}
+	libraryPaths := manifest.libraryPaths(root)
+	if len(libraryPaths) == 0 {
+		return fmt.Errorf("no emulator files found for the current directory. Run apply first")
+	}
+
+	mods, err := modSources(manifest.AppID, sources, opts)
+	if err != nil {
+		return err
+	}
+	if len(mods) == 0 {
+		return fmt.Errorf("no mods to import. Give mod folders or use --workshop")
+	}
+
+	items := map[string]steam.WorkshopItem{}
+	if !opts.Offline {
+		ids := make([]string, len(mods))
+		for i, mod := range mods {
+			ids[i] = mod.id
+		}
+		if items, err = steam.FetchWorkshopItems(ids); err != nil {
+			log.Printf("WARN: %v. Using the folder names as titles.", err)
+		}
+	}
+
+	now := time.Now().Unix()
+	for _, libraryPath := range libraryPaths {
+		settingsDir := filepath.Join(libraryPath, "steam_settings")
+		modsPath := filepath.Join(settingsDir, ModsFileName)
+		entries, err := readMods(modsPath)
+		if err != nil {
+			return err
+		}
+
+		for _, mod := range mods {
+			entry, existed := entries[mod.id]
+			if entry == nil {
+				entry = map[string]any{}
+			}
+			if !existed {
+				entry["time_added"] = now
+			}
+
+			if opts.Link {
+				dir, err := filepath.Abs(mod.dir)
+				if err != nil {
+					return err
+				}
+				entry["path"] = dir
+				// Drop the copy of an earlier import so that only the linked folder is used,
+				// unless that copy is the folder being linked
+				copyDir, err := filepath.Abs(filepath.Join(settingsDir, "mods", mod.id))
+				if err != nil {
+					return err
+				}
+				if filepath.Clean(copyDir) != filepath.Clean(dir) {
+					if err := os.RemoveAll(copyDir); err != nil {
+						log.Printf("WARN: Failed to remove the old copy of mod %s: %v", mod.id, err)
+					}
+				}
+			} else {
+				if err := syncDir(mod.dir, filepath.Join(settingsDir, "mods", mod.id)); err != nil {
+					log.Printf("ERROR: Failed to copy mod %s: %v", mod.id, err)
+					continue
+				}
+				delete(entry, "path")
+			}
+			entries[mod.id] = entry
+
+			entry["workshop_item_url"] = fmt.Sprintf(workshopItemURL, mod.id)
+			entry["primary_filesize"] = dirSize(mod.dir)
+			if info, err := os.Stat(mod.dir); err == nil {
+				entry["time_updated"] = info.ModTime().Unix()
+			}
+			if _, ok := entry["title"]; !ok {
+				entry["title"] = filepath.Base(mod.dir)
+			}
+
+			item, ok := items[mod.id]
+			if !ok {
+				continue
+			}
+			entry["title"] = item.Title
+			entry["description"] = item.Description
+			entry["tags"] = strings.Join(item.Tags, ",")
+			entry["time_created"] = item.TimeCreated
+			entry["time_updated"] = item.TimeUpdated
+			if owner, err := strconv.ParseUint(item.Creator, 10, 64); err == nil {
+				entry["steam_id_owner"] = owner
+			}
+			if item.FileName != "" {
+				entry["primary_filename"] = item.FileName
+			}
+			if item.PreviewURL != "" {
+				imageDir := filepath.Join(settingsDir, "mod_images", mod.id)
+				name, err := downloadPreview(item.PreviewURL, imageDir)
+				if err != nil {
+					log.Printf("WARN: Failed to download the preview of mod %s: %v", mod.id, err)
+				} else {
+					entry["preview_filename"] = name
+					if info, err := os.Stat(filepath.Join(imageDir, name)); err == nil {
+						entry["preview_filesize"] = info.Size()
+					}
+				}
+			}
+		}
+
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(settingsDir, 0755); err != nil {
+			return fmt.Errorf("failed to create steam_settings directory: %w", err)
+		}
+		if err := os.WriteFile(modsPath, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", ModsFileName, err)
+		}
+		log.Printf("INFO: Wrote %d mod(s) to %s", len(entries), modsPath)
+	}
+
+	log.Printf("SUCCESS: Imported %d mod(s).", len(mods))
+	return nil
+}
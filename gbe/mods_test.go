@@ -0,0 +1,115 @@
+package gbe
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportMods(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	game := t.TempDir()
+	manifest := &Manifest{AppID: "480", Targets: map[string]TargetState{"libsteam_api.so": {Hash: "x"}}}
+	if err := manifest.Save(game); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// An existing entry keeps the keys this tool does not manage
+	settingsDir := filepath.Join(game, "steam_settings")
+	if err := os.MkdirAll(settingsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := `{"111": {"title": "Old", "upvotes": 7, "steam_id_owner": 76561197960287930}}`
+	if err := os.WriteFile(filepath.Join(settingsDir, ModsFileName), []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mods := t.TempDir()
+	copied := filepath.Join(mods, "111")
+	linked := filepath.Join(mods, "My Mod")
+	for _, dir := range []string{copied, linked} {
+		if err := os.MkdirAll(filepath.Join(dir, "data"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "data", "mod.pak"), []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readEntries := func() map[string]map[string]any {
+		data, err := os.ReadFile(filepath.Join(settingsDir, ModsFileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entries map[string]map[string]any
+		if err := json.Unmarshal(data, &entries); err != nil {
+			t.Fatal(err)
+		}
+		return entries
+	}
+
+	// Test copying a mod folder named after its ID
+	if err := ImportMods([]string{copied}, ModsOptions{Offline: true, Dir: game}); err != nil {
+		t.Fatalf("ImportMods failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(settingsDir, "mods", "111", "data", "mod.pak")); err != nil {
+		t.Errorf("Expected the mod to be copied, got %v", err)
+	}
+	entry := readEntries()["111"]
+	if entry["title"] != "Old" || entry["upvotes"] != float64(7) || entry["primary_filesize"] != float64(7) {
+		t.Errorf("Expected the existing entry to be updated, got %v", entry)
+	}
+	data, err := os.ReadFile(filepath.Join(settingsDir, ModsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "76561197960287930") {
+		t.Errorf("Expected the owner SteamID to be kept exactly, got:\n%s", data)
+	}
+
+	// Test linking a folder with an explicit ID
+	if err := ImportMods([]string{"222=" + linked}, ModsOptions{Offline: true, Link: true, Dir: game}); err != nil {
+		t.Fatalf("ImportMods failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(settingsDir, "mods", "222")); !os.IsNotExist(err) {
+		t.Errorf("Expected the linked mod not to be copied, got %v", err)
+	}
+	if entry := readEntries()["222"]; entry["title"] != "My Mod" || entry["path"] != linked {
+		t.Errorf("Expected the linked entry, got %v", entry)
+	}
+
+	// Test that linking a copied mod removes the copy
+	if err := ImportMods([]string{copied}, ModsOptions{Offline: true, Link: true, Dir: game}); err != nil {
+		t.Fatalf("ImportMods failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(settingsDir, "mods", "111")); !os.IsNotExist(err) {
+		t.Errorf("Expected the old copy to be removed, got %v", err)
+	}
+	if entry := readEntries()["111"]; entry["path"] != copied {
+		t.Errorf("Expected path %q, got %v", copied, entry["path"])
+	}
+
+	// Test linking the folder already in steam_settings/mods, which is kept
+	inPlace := filepath.Join(settingsDir, "mods", "333")
+	if err := os.MkdirAll(inPlace, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inPlace, "mod.pak"), []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportMods([]string{inPlace + string(filepath.Separator)}, ModsOptions{Offline: true, Link: true, Dir: game}); err != nil {
+		t.Fatalf("ImportMods failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(inPlace, "mod.pak")); err != nil {
+		t.Errorf("Expected the linked folder to be kept, got %v", err)
+	}
+	if entry := readEntries()["333"]; entry["path"] != inPlace {
+		t.Errorf("Expected path %q, got %v", inPlace, entry["path"])
+	}
+
+	// Test a folder whose name is not an ID
+	if err := ImportMods([]string{linked}, ModsOptions{Offline: true, Dir: game}); err == nil {
+		t.Fatalf("ImportMods was expected to fail for a folder without an ID but succeeded")
+	}
+}
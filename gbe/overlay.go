@@ -0,0 +1,182 @@
+package gbe
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/util"
+)
+
+const (
+	// OverlayConfigFileName is the emulator's overlay settings file in steam_settings.
+	OverlayConfigFileName = "configs.overlay.ini"
+	// overlayAssetsDir holds the shared fonts/ and sounds/ for the overlay, inside GbeDir.
+	overlayAssetsDir = "overlay"
+)
+
+// overlayAssetDirs are the steam_settings subdirectories the overlay loads assets from.
+var overlayAssetDirs = []string{"fonts", "sounds"}
+
+// overlayAssetsPath returns the shared overlay assets directory.
+func overlayAssetsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, config.GbeDir, overlayAssetsDir), nil
+}
+
+// isZeroOverlay reports whether no overlay setting is made.
+func isZeroOverlay(settings config.OverlaySettings) bool {
+	return settings.Enabled == nil && settings.DisableAchievementNotification == nil &&
+		settings.DisableFriendNotification == nil && settings.DisableAchievementProgress == nil &&
+		settings.DisableWarnings == nil && settings.HookDelay == 0 && settings.Font == "" &&
+		len(settings.Extra) == 0
+}
+
+// writeOverlayConfig writes the settings to configs.overlay.ini in the
+// steam_settings directory next to a steam_api file and copies the shared
+// fonts and sounds there. Keys the settings do not set are left as they are,
+// and nothing is written when no setting is made.
+func writeOverlayConfig(libraryPath string, settings config.OverlaySettings) error {
+	if isZeroOverlay(settings) {
+		return nil
+	}
+	settingsDir := filepath.Join(libraryPath, "steam_settings")
+	iniPath := filepath.Join(settingsDir, OverlayConfigFileName)
+	ini, err := util.ReadINIFile(iniPath)
+	if err != nil {
+		return err
+	}
+
+	for _, option := range []struct {
+		key   string
+		value *bool
+	}{
+		{"enable_experimental_overlay", settings.Enabled},
+		{"disable_achievement_notification", settings.DisableAchievementNotification},
+		{"disable_friend_notification", settings.DisableFriendNotification},
+		{"disable_achievement_progress", settings.DisableAchievementProgress},
+		{"disable_warning_any", settings.DisableWarnings},
+	} {
+		if option.value != nil {
+			ini.Set("overlay::general", option.key, boolValue(*option.value))
+		}
+	}
+	if settings.HookDelay != 0 {
+		ini.Set("overlay::general", "hook_delay_sec", strconv.Itoa(settings.HookDelay))
+	}
+	if settings.Font != "" {
+		ini.Set("overlay::appearance", "Font_Override", settings.Font)
+	}
+	for section, keys := range settings.Extra {
+		for key, value := range keys {
+			ini.Set(section, key, value)
+		}
+	}
+	if err := util.WriteINIFile(iniPath, ini); err != nil {
+		return err
+	}
+	log.Printf("INFO: Wrote overlay settings to %s", iniPath)
+
+	assets, err := overlayAssetsPath()
+	if err != nil {
+		return err
+	}
+	for _, dir := range overlayAssetDirs {
+		if err := syncDir(filepath.Join(assets, dir), filepath.Join(settingsDir, dir)); err != nil {
+			return fmt.Errorf("failed to copy overlay %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// syncDir copies the files of srcDir into destDir, recursively, skipping
+// identical files. A missing srcDir copies nothing.
+func syncDir(srcDir, destDir string) error {
+	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(srcDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(destDir, rel)
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0755)
+		}
+		if info, err := d.Info(); err == nil && sameSize(dest, info.Size()) {
+			if destHash, err := util.GetHash(dest); err == nil {
+				if srcHash, err := util.GetHash(path); err == nil && srcHash == destHash {
+					return nil
+				}
+			}
+		}
+		return util.CopyFile(path, dest)
+	})
+}
+
+// sameSize reports whether the file at path exists with the given size.
+func sameSize(path string, size int64) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Size() == size
+}
+
+// ConfigureOverlay merges changes into the profile's overlay settings and
+// writes the result to every known game.
+func ConfigureOverlay(changes config.OverlaySettings) error {
+	if changes.Font != "" {
+		assets, err := overlayAssetsPath()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(assets, "fonts", changes.Font)); err != nil {
+			return fmt.Errorf("font '%s' not found in '%s': %w", changes.Font, filepath.Join(assets, "fonts"), err)
+		}
+	}
+
+	var settings config.OverlaySettings
+	if err := config.UpdateProfile(func(p *config.Profile) {
+		p.Overlay = p.Overlay.Merge(changes)
+		settings = p.Overlay
+	}); err != nil {
+		return err
+	}
+
+	games, err := KnownGames()
+	if err != nil {
+		return err
+	}
+	if len(games) == 0 {
+		log.Println("WARN: No games found. The settings will be applied by the next apply.")
+		return nil
+	}
+
+	updated := 0
+	for _, root := range games {
+		manifest, err := LoadManifest(root)
+		if err != nil {
+			log.Printf("WARN: %s: %v", root, err)
+			continue
+		}
+		if settings.Enabled != nil && *settings.Enabled && manifest.Flavour == "regular" {
+			log.Printf("WARN: '%s' uses the regular build, which has no overlay. Re-apply it with --flavour experimental.", root)
+		}
+		for _, libraryPath := range manifest.libraryPaths(root) {
+			if err := writeOverlayConfig(libraryPath, settings); err != nil {
+				log.Printf("ERROR: Failed to write overlay settings in %s: %v", libraryPath, err)
+			}
+		}
+		updated++
+	}
+	log.Printf("SUCCESS: Updated the overlay settings of %d game(s).", updated)
+	return nil
+}
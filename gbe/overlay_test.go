@@ -0,0 +1,74 @@
+package gbe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gbe_fork_helper/config"
+)
+
+func TestConfigureOverlay(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	fonts := filepath.Join(home, config.GbeDir, overlayAssetsDir, "fonts")
+	if err := os.MkdirAll(fonts, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(fonts, "custom.ttf"), []byte("font"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	game := t.TempDir()
+	manifest := &Manifest{AppID: "480", Flavour: "experimental", Targets: map[string]TargetState{
+		"bin/libsteam_api.so": {Hash: "x"},
+	}}
+	if err := manifest.Save(game); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := registerGame(game); err != nil {
+		t.Fatalf("registerGame failed: %v", err)
+	}
+	settingsDir := filepath.Join(game, "bin", "steam_settings")
+
+	// Test enabling the overlay with a font, which is copied to the game
+	enabled := true
+	if err := ConfigureOverlay(config.OverlaySettings{Enabled: &enabled, Font: "custom.ttf"}); err != nil {
+		t.Fatalf("ConfigureOverlay failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(settingsDir, "fonts", "custom.ttf")); err != nil {
+		t.Errorf("Expected the font to be copied, got %v", err)
+	}
+
+	// Test that later changes are merged into the saved settings
+	disabled := true
+	if err := ConfigureOverlay(config.OverlaySettings{DisableFriendNotification: &disabled}); err != nil {
+		t.Fatalf("ConfigureOverlay failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(settingsDir, OverlayConfigFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"enable_experimental_overlay=1", "disable_friend_notification=1", "Font_Override=custom.ttf"} {
+		if !strings.Contains(string(data), line+"\n") {
+			t.Errorf("Expected %s in %s, got:\n%s", line, OverlayConfigFileName, data)
+		}
+	}
+
+	// Test that the settings are saved in the profile
+	profile, err := config.LoadProfile()
+	if err != nil {
+		t.Fatalf("LoadProfile failed: %v", err)
+	}
+	if profile.Overlay.Enabled == nil || !*profile.Overlay.Enabled {
+		t.Errorf("Expected the overlay to be enabled in the profile, got %v", profile.Overlay.Enabled)
+	}
+	if profile.Overlay.Font != "custom.ttf" {
+		t.Errorf("Expected font %q, got %q", "custom.ttf", profile.Overlay.Font)
+	}
+
+	// Test a font that is not in the overlay assets
+	if err := ConfigureOverlay(config.OverlaySettings{Font: "missing.ttf"}); err == nil {
+		t.Fatalf("ConfigureOverlay was expected to fail for a missing font but succeeded")
+	}
+}
@@ -0,0 +1,370 @@
+package gbe
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gbe_fork_helper/steam"
+)
+
+// RunOptions holds the optional settings for RunGame.
+type RunOptions struct {
+	// Exe overrides the game executable, relative to the game directory. It
+	// is saved to the manifest for later runs.
+	Exe string
+	// Wine overrides how Windows games are run. It is saved to the manifest.
+	Wine WineConfig
+	// Wrap runs the given arguments as the complete command line, as Steam
+	// passes it for %command%, only adding the emulator's environment.
+	Wrap bool
+}
+
+// ignoredExecutables are executables shipped with games that are never the game itself.
+var ignoredExecutables = []string{
+	"steamclient_loader", "unitycrashhandler", "crashreport", "crashhandler",
+	"vcredist", "dxsetup", "dotnet", "setup", "uninstall", "unins000",
+	"generate_interfaces",
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveGameDir returns the game directory for a path or an installed AppID.
+func resolveGameDir(target string) (string, error) {
+	if _, err := os.Stat(target); err == nil || !isDigits(target) {
+		return target, nil
+	}
+	app, err := steam.FindApp(target)
+	if err != nil {
+		return "", err
+	}
+	return app.InstallPath(), nil
+}
+
+// findExecutables lists likely game executables in root for the platform,
+// searching two directory levels deep.
+func findExecutables(root, platform string) ([]string, error) {
+	windows := strings.HasPrefix(platform, "win")
+	var candidates []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		if d.IsDir() {
+			if strings.Count(rel, string(filepath.Separator)) >= 2 || d.Name() == "steam_settings" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		lower := strings.ToLower(d.Name())
+		for _, ignored := range ignoredExecutables {
+			if strings.Contains(lower, ignored) {
+				return nil
+			}
+		}
+		if windows {
+			if strings.HasSuffix(lower, ".exe") {
+				candidates = append(candidates, rel)
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Mode()&0111 == 0 || strings.Contains(lower, ".so") {
+			return nil
+		}
+		if f, err := elf.Open(path); err == nil {
+			if f.Type == elf.ET_EXEC || f.Type == elf.ET_DYN {
+				candidates = append(candidates, rel)
+			}
+			f.Close()
+		} else if isScript(path) {
+			candidates = append(candidates, rel)
+		}
+		return nil
+	})
+	return candidates, err
+}
+
+// launchExecutable returns the executable of the first Steam launch
+// configuration for the platform that exists in root.
+func launchExecutable(root, platform string, entries []steam.LaunchEntry) string {
+	osName := "linux"
+	if strings.HasPrefix(platform, "win") {
+		osName = "windows"
+	}
+	for _, entry := range entries {
+		if entry.OSList != "" && !strings.Contains(entry.OSList, osName) {
+			continue
+		}
+		exe := filepath.FromSlash(strings.ReplaceAll(entry.Executable, `\`, "/"))
+		if _, err := os.Stat(filepath.Join(root, exe)); err == nil {
+			return exe
+		}
+	}
+	return ""
+}
+
+// isScript reports whether path starts with a shebang line.
+func isScript(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	header := make([]byte, 2)
+	n, _ := f.Read(header)
+	return n == 2 && bytes.Equal(header, []byte("#!"))
+}
+
+// gameAppID returns the AppID from the manifest or the steam_appid.txt written by apply.
+func gameAppID(root string, manifest *Manifest) string {
+	if manifest.AppID != "" {
+		return manifest.AppID
+	}
+	for key := range manifest.Targets {
+		data, err := os.ReadFile(filepath.Join(root, filepath.Dir(filepath.FromSlash(key)), "steam_appid.txt"))
+		if err == nil {
+			return strings.TrimSpace(string(data))
+		}
+	}
+	return ""
+}
+
+// gameCommand builds the command line for exe, running Windows executables
+// through Wine or Proton on other hosts.
+func gameCommand(wine WineConfig, appID, exe string, args []string) (*exec.Cmd, error) {
+	if needsWine(exe) {
+		return wineCommand(wine, appID, exe, args)
+	}
+	cmd := exec.Command(exe, args...)
+	cmd.Env = os.Environ()
+	return cmd, nil
+}
+
+// libraryDirs returns the directories holding the replaced steam_api files,
+// for the dynamic loader search path.
+func libraryDirs(root string, manifest *Manifest) []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	for key := range manifest.Targets {
+		dir := filepath.Join(root, filepath.Dir(filepath.FromSlash(key)))
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	slices.Sort(dirs)
+	return dirs
+}
+
+// emulatorEnv returns the environment variables the emulator expects: the
+// AppID, and for Linux builds a library search path finding the replaced
+// steam_api files first.
+func emulatorEnv(appID, platform string, libDirs []string, exeDir string) []string {
+	env := []string{"SteamAppId=" + appID, "SteamGameId=" + appID}
+	if strings.HasPrefix(platform, "win") {
+		return env
+	}
+	ldPath := append([]string{}, libDirs...)
+	if !slices.Contains(ldPath, exeDir) {
+		ldPath = append(ldPath, exeDir)
+	}
+	if existing := os.Getenv("LD_LIBRARY_PATH"); existing != "" {
+		ldPath = append(ldPath, existing)
+	}
+	return append(env, "LD_LIBRARY_PATH="+strings.Join(ldPath, string(os.PathListSeparator)))
+}
+
+// logFiles returns the sizes of the emulator log files under dirs.
+func logFiles(dirs []string) map[string]int64 {
+	sizes := make(map[string]int64)
+	for _, dir := range dirs {
+		for _, pattern := range []string{"STEAM_LOG*", filepath.Join("steam_settings", "*.log")} {
+			matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+			for _, path := range matches {
+				if info, err := os.Stat(path); err == nil {
+					sizes[path] = info.Size()
+				}
+			}
+		}
+	}
+	return sizes
+}
+
+// tailLogs prints lines appended to emulator log files under dirs until done
+// is closed. Content present in offsets, recorded before launch, is skipped.
+func tailLogs(dirs []string, offsets map[string]int64, done <-chan struct{}) {
+	scan := func() {
+		for path, size := range logFiles(dirs) {
+			if size > offsets[path] {
+				offsets[path] = printFrom(path, offsets[path])
+			}
+		}
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			// Pick up whatever was written before the game exited
+			scan()
+			return
+		case <-ticker.C:
+			scan()
+		}
+	}
+}
+
+// printFrom prints the complete lines of path from offset, returning the new offset.
+func printFrom(path string, offset int64) int64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return offset
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, 0); err != nil {
+		return offset
+	}
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return offset
+		}
+		offset += int64(len(line))
+		log.Printf("[emu] %s", strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// RunGame launches a patched game with the environment the emulator expects.
+// The target is a game directory or the AppID of an installed game.
+func RunGame(target string, args []string, opts RunOptions) error {
+	root, err := resolveGameDir(target)
+	if err != nil {
+		return err
+	}
+	manifest, err := LoadManifest(root)
+	if err != nil {
+		return err
+	}
+
+	appID := gameAppID(root, manifest)
+	if appID == "" && isDigits(target) {
+		appID = target
+	}
+	if appID == "" {
+		return fmt.Errorf("no AppID known for '%s'. Run apply first", root)
+	}
+
+	if opts.Exe != "" || opts.Wine != (WineConfig{}) {
+		if opts.Exe != "" {
+			if _, err := os.Stat(filepath.Join(root, opts.Exe)); err != nil {
+				return fmt.Errorf("executable not found: %w", err)
+			}
+			manifest.Exe = filepath.ToSlash(opts.Exe)
+		}
+		manifest.Wine = manifest.Wine.Merge(opts.Wine)
+		if err := manifest.Save(root); err != nil {
+			log.Printf("WARN: %v", err)
+		}
+	}
+
+	var cmd *exec.Cmd
+	exeDir := root
+	if opts.Wrap {
+		if len(args) == 0 {
+			return fmt.Errorf("no command given to wrap")
+		}
+		cmd = exec.Command(args[0], args[1:]...)
+		cmd.Env = os.Environ()
+	} else {
+		exe := manifest.Loader
+		if exe == "" {
+			exe = manifest.Exe
+		}
+		if exe == "" {
+			// appmanifest_<appid>.acf files hold no launch configurations, so
+			// they are read from the local appinfo cache
+			if entries, err := steam.AppLaunchEntries(appID); err == nil {
+				exe = launchExecutable(root, manifest.Platform, entries)
+			}
+		}
+		if exe == "" {
+			candidates, err := findExecutables(root, manifest.Platform)
+			if err != nil {
+				return fmt.Errorf("failed to search for executables: %w", err)
+			}
+			if len(candidates) != 1 {
+				if len(candidates) == 0 {
+					return fmt.Errorf("no game executable found in '%s'. Select one with --exe", root)
+				}
+				return fmt.Errorf("several executables found in '%s': %s. Select one with --exe", root, strings.Join(candidates, ", "))
+			}
+			exe = candidates[0]
+		}
+		exePath, err := filepath.Abs(filepath.Join(root, filepath.FromSlash(exe)))
+		if err != nil {
+			return err
+		}
+
+		if cmd, err = gameCommand(manifest.Wine, appID, exePath, args); err != nil {
+			return err
+		}
+		exeDir = filepath.Dir(exePath)
+		cmd.Dir = exeDir
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	libDirs := libraryDirs(root, manifest)
+	cmd.Env = append(cmd.Env, emulatorEnv(appID, manifest.Platform, libDirs, exeDir)...)
+
+	log.Printf("INFO: Launching '%s' with AppID %s...", strings.Join(cmd.Args, " "), appID)
+	logDirs := append(libDirs, exeDir)
+	offsets := logFiles(logDirs)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to launch game: %w", err)
+	}
+
+	done := make(chan struct{})
+	tailed := make(chan struct{})
+	go func() {
+		tailLogs(logDirs, offsets, done)
+		close(tailed)
+	}()
+	err = cmd.Wait()
+	close(done)
+	<-tailed
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("game exited with status %d", exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run game: %w", err)
+	}
+	log.Println("SUCCESS: Game exited.")
+	return nil
+}
@@ -0,0 +1,138 @@
+package gbe
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gbe_fork_helper/steam"
+)
+
+func TestFindExecutables(t *testing.T) {
+	root := t.TempDir()
+	for name, mode := range map[string]os.FileMode{
+		"start.sh":                    0755,
+		"bin/game":                    0755,
+		"bin/libsteam_api.so":         0755,
+		"bin/readme.txt":              0644,
+		"bin/UnityCrashHandler64":     0755,
+		"bin/deep/tools/editor":       0755,
+		"steam_settings/helper":       0755,
+		"Game.exe":                    0644,
+		"bin/setup.exe":               0644,
+		"bin/win64/deep/launcher.exe": 0644,
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Test the Linux candidates: executable files two levels deep at most
+	candidates, err := findExecutables(root, "linux")
+	if err != nil {
+		t.Fatalf("findExecutables failed: %v", err)
+	}
+	expected := []string{filepath.Join("bin", "game"), "start.sh"}
+	if !reflect.DeepEqual(candidates, expected) {
+		t.Errorf("Expected %v, got %v", expected, candidates)
+	}
+
+	// Test the Windows candidates: .exe files, except installers
+	candidates, err = findExecutables(root, "win64")
+	if err != nil {
+		t.Fatalf("findExecutables failed: %v", err)
+	}
+	expected = []string{"Game.exe"}
+	if !reflect.DeepEqual(candidates, expected) {
+		t.Errorf("Expected %v, got %v", expected, candidates)
+	}
+}
+
+func TestLaunchExecutable(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"game.x86_64", "bin/Game.exe"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries := []steam.LaunchEntry{
+		{Executable: `bin\Missing.exe`, OSList: "windows"},
+		{Executable: `bin\Game.exe`, OSList: "windows"},
+		{Executable: "game.x86_64", OSList: "linux"},
+	}
+
+	// Test the first existing entry of each platform
+	if exe := launchExecutable(root, "win64", entries); exe != filepath.Join("bin", "Game.exe") {
+		t.Errorf("Expected %q, got %q", filepath.Join("bin", "Game.exe"), exe)
+	}
+	if exe := launchExecutable(root, "linux", entries); exe != "game.x86_64" {
+		t.Errorf("Expected %q, got %q", "game.x86_64", exe)
+	}
+
+	// Test without a matching entry
+	if exe := launchExecutable(root, "linux", entries[:2]); exe != "" {
+		t.Errorf("Expected no executable, got %q", exe)
+	}
+}
+
+func TestGameAppID(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "bin", "steam_appid.txt"), []byte("480\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	targets := map[string]TargetState{"bin/libsteam_api.so": {}}
+
+	// Test the manifest's AppID first
+	if appID := gameAppID(root, &Manifest{AppID: "570", Targets: targets}); appID != "570" {
+		t.Errorf("Expected AppID 570, got %q", appID)
+	}
+
+	// Test the steam_appid.txt next to a replaced file
+	if appID := gameAppID(root, &Manifest{Targets: targets}); appID != "480" {
+		t.Errorf("Expected AppID 480, got %q", appID)
+	}
+
+	// Test without either
+	if appID := gameAppID(root, &Manifest{}); appID != "" {
+		t.Errorf("Expected no AppID, got %q", appID)
+	}
+}
+
+func TestEmulatorEnv(t *testing.T) {
+	t.Setenv("LD_LIBRARY_PATH", "/usr/lib/extra")
+	root := t.TempDir()
+	manifest := &Manifest{Targets: map[string]TargetState{
+		"bin/libsteam_api.so":         {},
+		"bin/plugins/libsteam_api.so": {},
+	}}
+	libDirs := libraryDirs(root, manifest)
+	exeDir := filepath.Join(root, "bin")
+
+	// Test a Linux build, finding the replaced files first
+	env := emulatorEnv("480", "linux", libDirs, exeDir)
+	ldPath := strings.Join([]string{exeDir, filepath.Join(root, "bin", "plugins"), "/usr/lib/extra"}, string(os.PathListSeparator))
+	expected := []string{"SteamAppId=480", "SteamGameId=480", "LD_LIBRARY_PATH=" + ldPath}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expected %v, got %v", expected, env)
+	}
+
+	// Test a Windows build, which needs no library path
+	env = emulatorEnv("480", "win64", libDirs, exeDir)
+	expected = []string{"SteamAppId=480", "SteamGameId=480"}
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expected %v, got %v", expected, env)
+	}
+}
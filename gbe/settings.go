@@ -0,0 +1,57 @@
+package gbe
+
+import (
+	"log"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/steam"
+)
+
+// configureSettings writes the emulator settings of a game into the
+// steam_settings next to each of its steam_api files or loader, then applies
+// the steam_settings template on top unless the game opted out. Failures are
+// logged, as the emulator runs without any of these files.
+func configureSettings(appID string, libraryPaths []string, manifest *Manifest, profile *config.Profile) {
+	if len(libraryPaths) == 0 {
+		return
+	}
+	dlcOpts := dlcOptions(appID, manifest, profile)
+	settings := mainSettings(manifest, profile)
+	leaderboards, leaderboardsErr := steam.DefaultProvider().Leaderboards(appID)
+	if leaderboardsErr != nil {
+		log.Printf("INFO: No leaderboards for AppID %s: %v", appID, leaderboardsErr)
+	}
+	installed, err := installedAppIDs(appID, manifest, profile)
+	if err != nil {
+		log.Printf("WARN: %v. Every app is reported as installed.", err)
+	}
+
+	for _, libraryPath := range libraryPaths {
+		if err := steam.FetchDLCs(appID, libraryPath, dlcOpts); err != nil {
+			log.Printf("WARN: Failed to fetch and configure DLCs for AppID %s in %s: %v", appID, libraryPath, err)
+		}
+		if err := writeMainConfig(libraryPath, settings); err != nil {
+			log.Printf("WARN: Failed to write emulator settings in %s: %v", libraryPath, err)
+		}
+		if err := writeOverlayConfig(libraryPath, profile.Overlay); err != nil {
+			log.Printf("WARN: Failed to write overlay settings in %s: %v", libraryPath, err)
+		}
+		// Keep the leaderboards written earlier when none could be fetched
+		if leaderboardsErr == nil {
+			if err := writeLeaderboards(libraryPath, leaderboards); err != nil {
+				log.Printf("WARN: Failed to write leaderboards in %s: %v", libraryPath, err)
+			}
+		}
+		if err := writeGroups(libraryPath, profile.SubscribedGroups); err != nil {
+			log.Printf("WARN: Failed to write subscribed groups in %s: %v", libraryPath, err)
+		}
+		if err := writeInstalledApps(libraryPath, installed); err != nil {
+			log.Printf("WARN: Failed to write installed apps in %s: %v", libraryPath, err)
+		}
+		if useTemplate(manifest) {
+			if err := applyTemplate(libraryPath); err != nil {
+				log.Printf("WARN: Failed to apply the steam_settings template in %s: %v", libraryPath, err)
+			}
+		}
+	}
+}
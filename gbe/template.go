@@ -0,0 +1,146 @@
+package gbe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/util"
+)
+
+// templateDir holds the files applied on top of every game's steam_settings, inside GbeDir.
+const templateDir = "steam_settings_template"
+
+// templatePath returns the shared steam_settings template directory.
+func templatePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, config.GbeDir, templateDir), nil
+}
+
+// useTemplate reports whether the template applies to a game, which it
+// does unless the game opted out.
+func useTemplate(manifest *Manifest) bool {
+	return manifest.Template == nil || *manifest.Template
+}
+
+// applyTemplate applies the template directory on top of the steam_settings
+// in libraryPath. INI files are merged key by key and JSON objects are merged
+// recursively, the template's values winning. Other files are copied.
+func applyTemplate(libraryPath string) error {
+	srcDir, err := templatePath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
+		return nil
+	}
+	settingsDir := filepath.Join(libraryPath, "steam_settings")
+
+	applied := 0
+	err = filepath.WalkDir(srcDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(settingsDir, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".ini":
+			err = mergeINIFile(path, dest)
+		case ".json":
+			err = mergeJSONFile(path, dest)
+		default:
+			err = util.CopyFile(path, dest)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply template file %s: %w", rel, err)
+		}
+		applied++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		log.Printf("INFO: Applied %d template file(s) to %s", applied, settingsDir)
+	}
+	return nil
+}
+
+// mergeINIFile sets the keys of the INI file src in the INI file dest.
+func mergeINIFile(src, dest string) error {
+	template, err := util.ReadINIFile(src)
+	if err != nil {
+		return err
+	}
+	ini, err := util.ReadINIFile(dest)
+	if err != nil {
+		return err
+	}
+	ini.Merge(template)
+	return util.WriteINIFile(dest, ini)
+}
+
+// mergeJSONFile merges the JSON document src into the JSON document dest.
+func mergeJSONFile(src, dest string) error {
+	template, err := readJSONValue(src)
+	if err != nil {
+		return err
+	}
+	var merged any = template
+	if _, err := os.Stat(dest); err == nil {
+		current, err := readJSONValue(dest)
+		if err != nil {
+			return err
+		}
+		merged = mergeJSON(current, template)
+	}
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dest, append(data, '\n'), 0644)
+}
+
+// readJSONValue decodes a JSON file, keeping numbers exact.
+func readJSONValue(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode '%s': %w", path, err)
+	}
+	return v, nil
+}
+
+// mergeJSON merges override into base: objects are merged key by key, and
+// any other value of override replaces base's.
+func mergeJSON(base, override any) any {
+	baseObject, ok := base.(map[string]any)
+	overrideObject, ok2 := override.(map[string]any)
+	if !ok || !ok2 {
+		return override
+	}
+	for key, value := range overrideObject {
+		baseObject[key] = mergeJSON(baseObject[key], value)
+	}
+	return baseObject
+}
@@ -0,0 +1,79 @@
+package gbe
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/util"
+)
+
+func TestApplyTemplate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	template := filepath.Join(home, config.GbeDir, templateDir)
+	for name, content := range map[string]string{
+		"configs.overlay.ini":          "[overlay::general]\nenable_experimental_overlay=0\n",
+		"mods.json":                    `{"123": {"title": "Patched", "tags": "fix"}}`,
+		"custom_broadcasts.txt":        "192.168.1.20\n",
+		"controller/glyphs/button.png": "png",
+	} {
+		path := filepath.Join(template, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	libraryPath := t.TempDir()
+	settingsDir := filepath.Join(libraryPath, "steam_settings")
+	os.MkdirAll(settingsDir, 0755)
+	os.WriteFile(filepath.Join(settingsDir, "configs.overlay.ini"),
+		[]byte("[overlay::general]\nenable_experimental_overlay=1\nHook_Delay_Sec=5\n"), 0644)
+	os.WriteFile(filepath.Join(settingsDir, "mods.json"),
+		[]byte(`{"123": {"title": "Mod", "steam_id_owner": 76561197960287930}, "456": {"title": "Other"}}`), 0644)
+
+	if err := applyTemplate(libraryPath); err != nil {
+		t.Fatal(err)
+	}
+
+	ini, err := util.ReadINIFile(filepath.Join(settingsDir, "configs.overlay.ini"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := ini.Get("overlay::general", "enable_experimental_overlay"); v != "0" {
+		t.Errorf("enable_experimental_overlay = %q, want template's 0", v)
+	}
+	if v, _ := ini.Get("overlay::general", "Hook_Delay_Sec"); v != "5" {
+		t.Errorf("Hook_Delay_Sec = %q, want generated 5 kept", v)
+	}
+
+	data, err := os.ReadFile(filepath.Join(settingsDir, "mods.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mods map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &mods); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]map[string]string{
+		"123": {"title": `"Patched"`, "tags": `"fix"`, "steam_id_owner": "76561197960287930"},
+		"456": {"title": `"Other"`},
+	} {
+		for key, value := range want {
+			if got := string(mods[id][key]); got != value {
+				t.Errorf("mods.json %s.%s = %s, want %s", id, key, got, value)
+			}
+		}
+	}
+
+	for _, name := range []string{"custom_broadcasts.txt", "controller/glyphs/button.png"} {
+		if _, err := os.Stat(filepath.Join(settingsDir, name)); err != nil {
+			t.Errorf("%s not copied: %v", name, err)
+		}
+	}
+}
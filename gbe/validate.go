@@ -0,0 +1,475 @@
+package gbe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/util"
+)
+
+// SteamID ranges: account IDs 1 to 2^32-1 of individual accounts and of
+// clans in the public universe.
+const (
+	individualSteamIDBase = 76561197960265728
+	clanSteamIDBase       = 103582791429521408
+	maxAccountID          = 1<<32 - 1
+)
+
+// knownINIKeys lists the sections and keys the emulator reads from each
+// configs.*.ini file. A nil key list accepts any key.
+var knownINIKeys = map[string]map[string][]string{
+	"configs.user.ini": {
+		"user::general": {"account_name", "account_steamid", "language", "ip_country"},
+		"user::saves":   {"local_save_path", "saves_folder_name"},
+	},
+	"configs.main.ini": {
+		"main::general": {"new_app_ticket", "gc_token", "block_unknown_clients", "steam_deck",
+			"enable_account_avatar", "enable_voice_chat", "immediate_gameserver_stats",
+			"matchmaking_server_list_actual_type", "matchmaking_server_details_via_source_query",
+			"crash_printer_location"},
+		"main::stats": {"disable_leaderboards_create_unknown", "allow_unknown_stats",
+			"stat_achievement_progress_functionality", "save_only_higher_stat_achievement_progress",
+			"paginated_achievements_icons", "record_playtime"},
+		"main::connectivity": {"disable_lan_only", "disable_networking", "listen_port", "offline",
+			"disable_sharing_stats_with_gameserver", "disable_source_query",
+			"share_leaderboards_over_network", "disable_lobby_creation", "download_steamhttp_requests"},
+		"main::misc": {"achievements_bypass", "force_steamhttp_success", "disable_steamoverlaygameid_env_var",
+			"enable_steam_preowned_ids", "steam_game_stats_reports_dir", "free_weekend",
+			"use_32bit_inventory_item_ids"},
+	},
+	"configs.app.ini": {
+		"app::general":             {"build_id", "branch_name", "is_beta_branch"},
+		"app::dlcs":                nil,
+		"app::paths":               nil,
+		"app::cloud_save::general": {"create_default_dir", "create_specific_dirs"},
+		"app::cloud_save::win":     nil,
+		"app::cloud_save::linux":   nil,
+	},
+	"configs.overlay.ini": {
+		"overlay::general": {"enable_experimental_overlay", "hook_delay_sec", "renderer_detector_timeout_sec",
+			"disable_achievement_notification", "disable_friend_notification", "disable_achievement_progress",
+			"disable_warning_any", "disable_warning_bad_appid", "disable_warning_local_save",
+			"upload_achievements_icons_to_gpu", "fps_averaging_window"},
+		"overlay::appearance": nil,
+	},
+}
+
+// Diagnostic is a problem found in a game's emulator settings.
+type Diagnostic struct {
+	// Severity is ERROR for settings the emulator cannot use, or WARN.
+	Severity string
+	// File is the file concerned, relative to the directory of the steam_api file.
+	File    string
+	Message string
+}
+
+// validator collects the diagnostics of one steam_api directory.
+type validator struct {
+	libraryPath string
+	manifest    *Manifest
+	diagnostics []Diagnostic
+}
+
+func (v *validator) errorf(file, format string, args ...any) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{"ERROR", file, fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(file, format string, args ...any) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{"WARN", file, fmt.Sprintf(format, args...)})
+}
+
+// settingsFile returns the path of a file in steam_settings.
+func (v *validator) settingsFile(name string) string {
+	return filepath.Join(v.libraryPath, "steam_settings", filepath.FromSlash(name))
+}
+
+// readJSON decodes a JSON file in steam_settings, keeping numbers exact. It
+// returns false when the file is missing or invalid.
+func (v *validator) readJSON(name string, value any) bool {
+	data, err := os.ReadFile(v.settingsFile(name))
+	if os.IsNotExist(err) {
+		return false
+	}
+	if err != nil {
+		v.errorf("steam_settings/"+name, "%v", err)
+		return false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(value); err != nil {
+		v.errorf("steam_settings/"+name, "invalid JSON: %v", err)
+		return false
+	}
+	return true
+}
+
+// checkAppID checks steam_appid.txt, which the emulator needs to know the game.
+func (v *validator) checkAppID() {
+	data, err := os.ReadFile(filepath.Join(v.libraryPath, "steam_appid.txt"))
+	if os.IsNotExist(err) {
+		data, err = os.ReadFile(v.settingsFile("steam_appid.txt"))
+	}
+	if err != nil {
+		v.errorf("steam_appid.txt", "missing. Run apply or config generate to write it")
+		return
+	}
+	appID := strings.TrimSpace(string(data))
+	if id, err := strconv.ParseUint(appID, 10, 32); err != nil || id == 0 {
+		v.errorf("steam_appid.txt", "'%s' is not an AppID", appID)
+		return
+	}
+	if v.manifest.AppID != "" && appID != v.manifest.AppID {
+		v.errorf("steam_appid.txt", "AppID %s differs from %s applied to this game. Re-run apply", appID, v.manifest.AppID)
+	}
+}
+
+// checkINI checks the syntax, sections and keys of a configs.*.ini file.
+func (v *validator) checkINI(path string) {
+	name := filepath.Base(path)
+	file := "steam_settings/" + name
+	data, err := os.ReadFile(path)
+	if err != nil {
+		v.errorf(file, "%v", err)
+		return
+	}
+	known, ok := knownINIKeys[name]
+	if !ok {
+		v.warnf(file, "not read by the emulator. Expected one of configs.app.ini, configs.main.ini, configs.overlay.ini, configs.user.ini")
+	}
+
+	section := ""
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				v.errorf(file, "line %d: unterminated section header '%s'", n, line)
+				continue
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, found := known[section]; ok && !found {
+				v.warnf(file, "line %d: unknown section [%s]", n, section)
+			}
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !found || key == "" {
+			v.errorf(file, "line %d: expected key=value or [section], got '%s'", n, line)
+			continue
+		}
+		if section == "" {
+			v.warnf(file, "line %d: key '%s' is outside any section and is ignored", n, key)
+			continue
+		}
+		if seen[section+"."+key] {
+			v.warnf(file, "line %d: duplicate key '%s' in [%s], only one value is used", n, key, section)
+		}
+		seen[section+"."+key] = true
+		if keys, found := known[section]; found && keys != nil &&
+			!slices.ContainsFunc(keys, func(k string) bool { return strings.EqualFold(k, key) }) {
+			v.warnf(file, "line %d: unknown key '%s' in [%s]. Check its spelling", n, key, section)
+		}
+		v.checkINIValue(file, n, section, key, value)
+	}
+}
+
+// checkINIValue checks the values the emulator cannot work with.
+func (v *validator) checkINIValue(file string, n int, section, key, value string) {
+	switch {
+	case section == "user::general" && key == "account_steamid":
+		if !inSteamIDRange(value, individualSteamIDBase) {
+			v.errorf(file, "line %d: account_steamid %s is not an individual account SteamID (%d to %d)",
+				n, value, uint64(individualSteamIDBase+1), uint64(individualSteamIDBase+maxAccountID))
+		}
+	case section == "main::connectivity" && key == "listen_port":
+		if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+			v.errorf(file, "line %d: listen_port %s is not a port between 1 and 65535", n, value)
+		}
+	case (section == "app::dlcs" && key != "unlock_all") || section == "app::paths":
+		if id, err := strconv.ParseUint(key, 10, 32); err != nil || id == 0 {
+			v.errorf(file, "line %d: '%s' in [%s] is not a DLC AppID", n, key, section)
+		}
+	case section == "overlay::appearance" && strings.EqualFold(key, "Font_Override") && value != "":
+		if _, err := os.Stat(v.settingsFile("fonts/" + value)); err != nil {
+			v.errorf(file, "line %d: font '%s' not found in steam_settings/fonts", n, value)
+		}
+	}
+}
+
+// inSteamIDRange reports whether value is a SteamID with a valid account ID above base.
+func inSteamIDRange(value string, base uint64) bool {
+	id, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	return err == nil && id > base && id <= base+maxAccountID
+}
+
+// checkImage reports a missing image referenced by a settings file. Paths
+// are relative to steam_settings, or to dir within it.
+func (v *validator) checkImage(file, what, image, dir string) {
+	if image == "" || strings.HasPrefix(image, "http://") || strings.HasPrefix(image, "https://") {
+		return
+	}
+	for _, candidate := range []string{image, dir + "/" + image} {
+		if _, err := os.Stat(v.settingsFile(candidate)); err == nil {
+			return
+		}
+	}
+	v.errorf(file, "%s: image '%s' not found", what, image)
+}
+
+// checkAchievements checks achievements.json and the icons it references.
+func (v *validator) checkAchievements() {
+	const file = "steam_settings/" + AchievementsFileName
+	var achievements []map[string]any
+	if !v.readJSON(AchievementsFileName, &achievements) {
+		return
+	}
+	names := make(map[string]bool)
+	for i, achievement := range achievements {
+		name, _ := achievement["name"].(string)
+		what := fmt.Sprintf("achievement %d", i)
+		if name == "" {
+			v.errorf(file, "%s has no name", what)
+			continue
+		}
+		what = fmt.Sprintf("achievement '%s'", name)
+		if names[name] {
+			v.errorf(file, "%s is defined twice", what)
+		}
+		names[name] = true
+		switch achievement["displayName"].(type) {
+		case string, map[string]any:
+		default:
+			v.warnf(file, "%s has no displayName", what)
+		}
+		if hidden, ok := achievement["hidden"]; ok {
+			if s := fmt.Sprint(hidden); s != "0" && s != "1" {
+				v.errorf(file, "%s: hidden must be 0 or 1, not %s", what, s)
+			}
+		}
+		for _, key := range []string{"icon", "icon_gray", "icongray"} {
+			if icon, ok := achievement[key].(string); ok {
+				v.checkImage(file, what, icon, achievementImagesDir)
+			}
+		}
+	}
+}
+
+// checkStats checks the definitions in stats.json.
+func (v *validator) checkStats() {
+	const file = "steam_settings/" + StatsFileName
+	var stats []map[string]any
+	if !v.readJSON(StatsFileName, &stats) {
+		return
+	}
+	names := make(map[string]bool)
+	for i, stat := range stats {
+		name, _ := stat["name"].(string)
+		if name == "" {
+			v.errorf(file, "stat %d has no name", i)
+			continue
+		}
+		if names[name] {
+			v.errorf(file, "stat '%s' is defined twice", name)
+		}
+		names[name] = true
+		statType := fmt.Sprint(stat["type"])
+		if !slices.Contains([]string{"int", "float", "avgrate"}, statType) {
+			v.errorf(file, "stat '%s': type must be int, float or avgrate, not '%s'", name, statType)
+			continue
+		}
+		for _, key := range []string{"default", "global"} {
+			value, ok := stat[key]
+			if !ok {
+				continue
+			}
+			s := fmt.Sprint(value)
+			var err error
+			if statType == "int" {
+				_, err = strconv.ParseInt(s, 10, 32)
+			} else {
+				_, err = strconv.ParseFloat(s, 32)
+			}
+			if err != nil {
+				v.errorf(file, "stat '%s': %s '%s' is not a valid %s", name, key, s, statType)
+			}
+		}
+	}
+}
+
+// checkItems checks the inventory item definitions in items.json.
+func (v *validator) checkItems() {
+	const file = "steam_settings/" + ItemsFileName
+	var items map[string]any
+	if !v.readJSON(ItemsFileName, &items) {
+		return
+	}
+	for id, value := range items {
+		if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+			v.errorf(file, "item '%s': keys must be item definition IDs", id)
+		}
+		item, ok := value.(map[string]any)
+		if !ok {
+			v.errorf(file, "item %s is not an object", id)
+			continue
+		}
+		if itemDefID, ok := item["itemdefid"]; ok && fmt.Sprint(itemDefID) != id {
+			v.errorf(file, "item %s has itemdefid %v", id, itemDefID)
+		}
+		if _, ok := item["name"]; !ok {
+			v.warnf(file, "item %s has no name", id)
+		}
+	}
+}
+
+// checkMods checks the owners and preview images in mods.json.
+func (v *validator) checkMods() {
+	const file = "steam_settings/" + ModsFileName
+	var mods map[string]map[string]any
+	if !v.readJSON(ModsFileName, &mods) {
+		return
+	}
+	for id, mod := range mods {
+		if owner, ok := mod["steam_id_owner"]; ok && !inSteamIDRange(fmt.Sprint(owner), individualSteamIDBase) {
+			v.errorf(file, "mod %s: steam_id_owner %v is not an individual account SteamID", id, owner)
+		}
+		if preview, ok := mod["preview_filename"].(string); ok {
+			v.checkImage(file, "mod "+id, preview, "mod_images/"+id)
+		}
+	}
+}
+
+// checkGroups checks the clan SteamIDs of the subscribed groups files.
+func (v *validator) checkGroups() {
+	for _, name := range []string{GroupsFileName, ClansFileName} {
+		data, err := os.ReadFile(v.settingsFile(name))
+		if err != nil {
+			continue
+		}
+		for n, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) > 0 && !inSteamIDRange(fields[0], clanSteamIDBase) {
+				v.errorf("steam_settings/"+name, "line %d: %s is not a group SteamID (%d to %d)",
+					n+1, fields[0], uint64(clanSteamIDBase+1), uint64(clanSteamIDBase+maxAccountID))
+			}
+		}
+	}
+}
+
+// checkInterfaces checks that steam_interfaces.txt lists the interfaces of
+// the game's original steam_api files, recorded in the manifest or scanned
+// from their backups.
+func (v *validator) checkInterfaces(root string) {
+	var expected []string
+	for _, platform := range config.PlatformConfig {
+		path := filepath.Join(v.libraryPath, platform.Target)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		key, err := filepath.Rel(root, path)
+		if err != nil {
+			continue
+		}
+		interfaces := v.manifest.Targets[filepath.ToSlash(key)].Interfaces
+		if len(interfaces) == 0 {
+			if backup, err := util.OriginalBackup(path); err == nil {
+				interfaces, _ = FindInterfaces(backup)
+			}
+		}
+		for _, name := range interfaces {
+			if !slices.Contains(expected, name) {
+				expected = append(expected, name)
+			}
+		}
+	}
+	if len(expected) == 0 {
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join(v.libraryPath, InterfacesFileName))
+	if err != nil {
+		v.errorf(InterfacesFileName, "missing. The game may fail to get its interfaces. Re-run apply")
+		return
+	}
+	listed := strings.Fields(string(data))
+	for _, name := range expected {
+		if !slices.Contains(listed, name) {
+			v.errorf(InterfacesFileName, "%s used by the original steam_api is missing. Re-run apply", name)
+		}
+	}
+	for _, name := range listed {
+		if !slices.Contains(expected, name) {
+			v.warnf(InterfacesFileName, "%s is not used by the original steam_api", name)
+		}
+	}
+}
+
+// validateLibrary checks the emulator settings next to one steam_api file.
+func validateLibrary(root, libraryPath string, manifest *Manifest) []Diagnostic {
+	v := &validator{libraryPath: libraryPath, manifest: manifest}
+	v.checkAppID()
+	v.checkInterfaces(root)
+	if info, err := os.Stat(filepath.Join(libraryPath, "steam_settings")); err != nil || !info.IsDir() {
+		v.warnf("steam_settings", "missing. The emulator runs with its defaults")
+		return v.diagnostics
+	}
+	inis, _ := filepath.Glob(v.settingsFile("configs.*.ini"))
+	for _, path := range inis {
+		v.checkINI(path)
+	}
+	v.checkAchievements()
+	v.checkStats()
+	v.checkItems()
+	v.checkMods()
+	v.checkGroups()
+	return v.diagnostics
+}
+
+// ValidateSettings checks the emulator settings of the game at path: a
+// game directory set up by apply, a directory holding a steam_api file, or
+// its steam_settings. Every problem is printed, and an error is returned if
+// any of them keeps the emulator from using the settings.
+func ValidateSettings(path string) error {
+	if filepath.Base(filepath.Clean(path)) == "steam_settings" {
+		path = filepath.Dir(filepath.Clean(path))
+	}
+	manifest, err := LoadManifest(path)
+	if err != nil {
+		return err
+	}
+	libraryPaths := manifest.libraryPaths(path)
+	if len(libraryPaths) == 0 {
+		libraryPaths = []string{path}
+	}
+
+	errorCount, warnCount := 0, 0
+	for _, libraryPath := range libraryPaths {
+		log.Printf("INFO: Checking %s", libraryPath)
+		for _, d := range validateLibrary(path, libraryPath, manifest) {
+			log.Printf("%s: %s: %s", d.Severity, filepath.Join(libraryPath, filepath.FromSlash(d.File)), d.Message)
+			if d.Severity == "ERROR" {
+				errorCount++
+			} else {
+				warnCount++
+			}
+		}
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("found %d error(s) and %d warning(s)", errorCount, warnCount)
+	}
+	log.Printf("SUCCESS: No errors found, %d warning(s).", warnCount)
+	return nil
+}
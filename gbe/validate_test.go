@@ -0,0 +1,108 @@
+package gbe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validFiles are the settings of a library path that pass validation, by
+// path relative to steam_settings.
+var validFiles = map[string]string{
+	"../steam_appid.txt":              "480\n",
+	"../libsteam_api.so":              "emulator",
+	"../steam_interfaces.txt":         "SteamClient020\nSteamUser023\nSteamFriends017\n",
+	"configs.user.ini":                "[user::general]\naccount_name=Player\naccount_steamid=76561197960287930\n",
+	"configs.main.ini":                "[main::connectivity]\nlisten_port=47584\noffline=1\n",
+	"achievements.json":               `[{"name": "WIN", "displayName": "Win", "hidden": 0, "icon": "achievement_images/win.jpg", "icon_gray": "win.jpg"}]`,
+	"stats.json":                      `[{"name": "kills", "type": "int", "default": "0"}, {"name": "ratio", "type": "float", "default": "0.5"}]`,
+	"items.json":                      `{"100": {"itemdefid": "100", "name": "Hat"}}`,
+	"subscribed_groups.txt":           "103582791429521412\n",
+	achievementImagesDir + "/win.jpg": "jpg",
+}
+
+// validManifest records the interfaces of the original libsteam_api.so.
+var validManifest = &Manifest{
+	AppID:   "480",
+	Targets: map[string]TargetState{"libsteam_api.so": {Interfaces: []string{"SteamClient020", "SteamUser023", "SteamFriends017"}}},
+}
+
+func TestValidateLibrary(t *testing.T) {
+	root := t.TempDir()
+	settingsDir := filepath.Join(root, "steam_settings")
+	if err := os.MkdirAll(filepath.Join(settingsDir, achievementImagesDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(settingsDir, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range validFiles {
+		writeFile(name, content)
+	}
+	validate := func() []string {
+		var report []string
+		for _, d := range validateLibrary(root, root, validManifest) {
+			report = append(report, d.Severity+" "+d.File+": "+d.Message)
+		}
+		return report
+	}
+
+	// Test valid settings
+	if report := validate(); len(report) != 0 {
+		t.Errorf("Expected no diagnostics, got:\n%s", strings.Join(report, "\n"))
+	}
+
+	// Test each file with errors, restoring it afterwards
+	for _, test := range []struct {
+		name, content string
+		expected      []string
+	}{
+		{"../steam_interfaces.txt", "SteamClient020\nSteamUser023\n", []string{
+			"ERROR steam_interfaces.txt: SteamFriends017",
+		}},
+		{"configs.user.ini", "[user::general]\naccount_steamid=12345\naccount_nmae=Player\n", []string{
+			"ERROR steam_settings/configs.user.ini: line 2: account_steamid 12345",
+			"WARN steam_settings/configs.user.ini: line 3: unknown key 'account_nmae'",
+		}},
+		{"configs.main.ini", "[main::connectivity]\nlisten_port=70000\noffline\n", []string{
+			"ERROR steam_settings/configs.main.ini: line 2: listen_port 70000",
+			"ERROR steam_settings/configs.main.ini: line 3: expected key=value",
+		}},
+		{"achievements.json", `[{"name": "WIN", "displayName": "Win", "hidden": 0, "icon_gray": "missing.jpg"}, {"name": "WIN", "displayName": "Again", "hidden": 2}]`, []string{
+			"ERROR steam_settings/achievements.json: achievement 'WIN': image 'missing.jpg' not found",
+			"ERROR steam_settings/achievements.json: achievement 'WIN' is defined twice",
+			"ERROR steam_settings/achievements.json: achievement 'WIN': hidden must be 0 or 1, not 2",
+		}},
+		{"stats.json", `[{"name": "ratio", "type": "double", "default": "0"}]`, []string{
+			"ERROR steam_settings/stats.json: stat 'ratio': type must be int, float or avgrate",
+		}},
+		{"items.json", `{"hat": {"itemdefid": "101", "name": "Cap"}}`, []string{
+			"ERROR steam_settings/items.json: item 'hat': keys must be item definition IDs",
+			"ERROR steam_settings/items.json: item hat has itemdefid 101",
+		}},
+		{"subscribed_groups.txt", "103582791429521412\n76561197960287930\n", []string{
+			"ERROR steam_settings/subscribed_groups.txt: line 2: 76561197960287930 is not a group SteamID",
+		}},
+	} {
+		writeFile(test.name, test.content)
+		report := validate()
+		for _, prefix := range test.expected {
+			found := 0
+			for _, line := range report {
+				if strings.HasPrefix(line, prefix) {
+					found++
+				}
+			}
+			if found != 1 {
+				t.Errorf("Expected one diagnostic %q, got %d in:\n%s", prefix, found, strings.Join(report, "\n"))
+			}
+		}
+		if len(report) != len(test.expected) {
+			t.Errorf("Expected %d diagnostic(s) for %s, got %d:\n%s", len(test.expected), test.name, len(report), strings.Join(report, "\n"))
+		}
+		writeFile(test.name, validFiles[test.name])
+	}
+}
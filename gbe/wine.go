@@ -0,0 +1,139 @@
+package gbe
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"gbe_fork_helper/config"
+	"gbe_fork_helper/steam"
+)
+
+// WineConfig selects how Windows executables are run on other hosts.
+type WineConfig struct {
+	// Proton is the name or path of a Proton install to run through.
+	Proton string `json:"proton,omitempty"`
+	// Wine is the wine executable used when Proton is not selected.
+	Wine string `json:"wine,omitempty"`
+	// Prefix is the Wine prefix, or the compatdata directory for Proton.
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// Merge returns c with the fields set in override replacing its own.
+func (c WineConfig) Merge(override WineConfig) WineConfig {
+	if override.Proton != "" {
+		c.Proton = override.Proton
+		c.Wine = ""
+	}
+	if override.Wine != "" {
+		c.Wine = override.Wine
+		c.Proton = ""
+	}
+	if override.Prefix != "" {
+		c.Prefix = override.Prefix
+	}
+	return c
+}
+
+// needsWine reports whether exe is a Windows executable on a non-Windows host.
+func needsWine(exe string) bool {
+	return runtime.GOOS != "windows" && strings.HasSuffix(strings.ToLower(exe), ".exe")
+}
+
+// defaultPrefix returns the Proton compatdata directory for an AppID: the
+// game's own one when it is installed through Steam, otherwise one under GbeDir.
+func defaultPrefix(appID string) (string, error) {
+	if appID != "" {
+		if app, err := steam.FindApp(appID); err == nil {
+			return filepath.Join(app.Library, "steamapps", "compatdata", appID), nil
+		}
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	name := appID
+	if name == "" {
+		name = "default"
+	}
+	return filepath.Join(homeDir, config.GbeDir, "prefixes", name), nil
+}
+
+// winePrefix returns the Wine prefix a Windows game runs in, as selected by
+// wineCommand. For a Proton compatdata directory it is the pfx inside.
+func winePrefix(cfg WineConfig, appID string) (string, error) {
+	prefix := cfg.Prefix
+	if prefix == "" && cfg.Proton != "" {
+		var err error
+		if prefix, err = defaultPrefix(appID); err != nil {
+			return "", err
+		}
+	}
+	if prefix == "" {
+		if prefix = os.Getenv("WINEPREFIX"); prefix == "" {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("failed to get user home directory: %w", err)
+			}
+			prefix = filepath.Join(homeDir, ".wine")
+		}
+	}
+	if _, err := os.Stat(filepath.Join(prefix, "pfx")); err == nil {
+		prefix = filepath.Join(prefix, "pfx")
+	}
+	return prefix, nil
+}
+
+// wineCommand builds a command running a Windows executable through Proton
+// when one is selected, or through Wine otherwise.
+func wineCommand(cfg WineConfig, appID, exe string, args []string) (*exec.Cmd, error) {
+	if cfg.Proton != "" {
+		proton, ok := steam.FindProton(cfg.Proton)
+		if !ok {
+			return nil, fmt.Errorf("Proton install '%s' not found. Run the proton command to list installs", cfg.Proton)
+		}
+		prefix := cfg.Prefix
+		if prefix == "" {
+			var err error
+			if prefix, err = defaultPrefix(appID); err != nil {
+				return nil, err
+			}
+		}
+		if err := os.MkdirAll(prefix, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create prefix '%s': %w", prefix, err)
+		}
+
+		cmd := exec.Command(proton.Script(), append([]string{"run", exe}, args...)...)
+		cmd.Env = append(os.Environ(), "STEAM_COMPAT_DATA_PATH="+prefix)
+		if steamRoot, err := steam.FindSteamRoot(); err == nil {
+			cmd.Env = append(cmd.Env, "STEAM_COMPAT_CLIENT_INSTALL_PATH="+steamRoot)
+		}
+		if appID != "" {
+			cmd.Env = append(cmd.Env, "SteamAppId="+appID)
+		}
+		return cmd, nil
+	}
+
+	wine := cfg.Wine
+	if wine == "" {
+		wine = "wine"
+	}
+	winePath, err := exec.LookPath(wine)
+	if err != nil {
+		return nil, fmt.Errorf("running a Windows executable requires Wine or Proton: %w", err)
+	}
+	cmd := exec.Command(winePath, append([]string{exe}, args...)...)
+	cmd.Env = os.Environ()
+	if cfg.Prefix != "" {
+		prefix := cfg.Prefix
+		// A Proton compatdata directory keeps the actual prefix in pfx
+		if _, err := os.Stat(filepath.Join(prefix, "pfx")); err == nil {
+			prefix = filepath.Join(prefix, "pfx")
+		}
+		cmd.Env = append(cmd.Env, "WINEPREFIX="+prefix)
+	}
+	return cmd, nil
+}
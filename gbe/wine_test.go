@@ -0,0 +1,85 @@
+package gbe
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"gbe_fork_helper/config"
+)
+
+func TestWineConfigMerge(t *testing.T) {
+	for _, test := range []struct {
+		base, override, expected WineConfig
+	}{
+		// Unset fields keep the base
+		{WineConfig{Proton: "Proton 9.0", Prefix: "/pfx"}, WineConfig{}, WineConfig{Proton: "Proton 9.0", Prefix: "/pfx"}},
+		// Wine and Proton replace each other
+		{WineConfig{Proton: "Proton 9.0", Prefix: "/pfx"}, WineConfig{Wine: "wine64"}, WineConfig{Wine: "wine64", Prefix: "/pfx"}},
+		{WineConfig{Wine: "wine64"}, WineConfig{Proton: "GE-Proton9-20"}, WineConfig{Proton: "GE-Proton9-20"}},
+		{WineConfig{Wine: "wine64", Prefix: "/pfx"}, WineConfig{Prefix: "/other"}, WineConfig{Wine: "wine64", Prefix: "/other"}},
+	} {
+		if got := test.base.Merge(test.override); got != test.expected {
+			t.Errorf("Expected %+v, got %+v", test.expected, got)
+		}
+	}
+}
+
+func TestWineCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	steamRoot := filepath.Join(home, ".steam", "steam")
+	proton := filepath.Join(steamRoot, "steamapps", "common", "Proton 9.0")
+	if err := os.MkdirAll(proton, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(proton, "proton"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	wine := filepath.Join(t.TempDir(), "wine")
+	if err := os.WriteFile(wine, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Test Proton with a prefix of its own under GbeDir
+	cmd, err := wineCommand(WineConfig{Proton: "Proton 9.0"}, "480", "Game.exe", []string{"-windowed"})
+	if err != nil {
+		t.Fatalf("wineCommand failed: %v", err)
+	}
+	expected := []string{filepath.Join(proton, "proton"), "run", "Game.exe", "-windowed"}
+	if !reflect.DeepEqual(cmd.Args, expected) {
+		t.Errorf("Expected %v, got %v", expected, cmd.Args)
+	}
+	prefix := filepath.Join(home, config.GbeDir, "prefixes", "480")
+	for _, env := range []string{"STEAM_COMPAT_DATA_PATH=" + prefix, "STEAM_COMPAT_CLIENT_INSTALL_PATH=" + steamRoot, "SteamAppId=480"} {
+		if !slices.Contains(cmd.Env, env) {
+			t.Errorf("Expected %s in the environment", env)
+		}
+	}
+	if _, err := os.Stat(prefix); err != nil {
+		t.Errorf("Expected the prefix to be created, got %v", err)
+	}
+
+	// Test Wine in a Proton compatdata directory, which keeps the prefix in pfx
+	compatdata := t.TempDir()
+	if err := os.Mkdir(filepath.Join(compatdata, "pfx"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cmd, err = wineCommand(WineConfig{Wine: wine, Prefix: compatdata}, "480", "Game.exe", nil)
+	if err != nil {
+		t.Fatalf("wineCommand failed: %v", err)
+	}
+	if expected := []string{wine, "Game.exe"}; !reflect.DeepEqual(cmd.Args, expected) {
+		t.Errorf("Expected %v, got %v", expected, cmd.Args)
+	}
+	if env := "WINEPREFIX=" + filepath.Join(compatdata, "pfx"); !slices.Contains(cmd.Env, env) {
+		t.Errorf("Expected %s in the environment", env)
+	}
+
+	// Test a Proton install that does not exist
+	if _, err := wineCommand(WineConfig{Proton: "Proton 1.0"}, "480", "Game.exe", nil); err == nil {
+		t.Errorf("wineCommand was expected to fail for a missing Proton install but succeeded")
+	}
+}
@@ -0,0 +1,135 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// cacheFileName is the name of the GitHub API response cache inside the GBE directory.
+const cacheFileName = ".github_cache.json"
+
+// cachedResponse is a GitHub API response stored for conditional requests.
+type cachedResponse struct {
+	ETag string          `json:"etag"`
+	Body json.RawMessage `json:"body"`
+}
+
+// loadCache reads the response cache, returning an empty cache if it is missing or unreadable.
+func loadCache(path string) map[string]cachedResponse {
+	cache := make(map[string]cachedResponse)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		log.Printf("WARN: Ignoring corrupt GitHub cache '%s': %v", path, err)
+		return make(map[string]cachedResponse)
+	}
+	return cache
+}
+
+// saveCache writes the response cache.
+func saveCache(path string, cache map[string]cachedResponse) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// fetchJSON fetches a GitHub API URL and decodes the response into v.
+// Requests are authenticated with GITHUB_TOKEN when set, and responses are
+// cached in gbeHome with their ETag so that an unchanged resource is served
+// from the cache after a 304 Not Modified, which does not count against the
+// rate limit.
+func fetchJSON(gbeHome, url string, v any) error {
+	cachePath := filepath.Join(gbeHome, cacheFileName)
+	cache := loadCache(cachePath)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	cached, hasCached := cache[url]
+	if hasCached && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch release information: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if hasCached {
+			log.Println("INFO: Release information unchanged, using cached response.")
+			return json.Unmarshal(cached.Body, v)
+		}
+		return fmt.Errorf("GitHub returned 304 Not Modified without a cached response")
+	default:
+		return apiError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		cache[url] = cachedResponse{ETag: etag, Body: body}
+		if err := saveCache(cachePath, cache); err != nil {
+			log.Printf("WARN: Failed to write GitHub cache '%s': %v", cachePath, err)
+		}
+	}
+	return nil
+}
+
+// apiError builds a descriptive error from a failed GitHub API response,
+// explaining rate limiting when that is the cause.
+func apiError(resp *http.Response) error {
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	body, _ := io.ReadAll(resp.Body)
+	json.Unmarshal(body, &apiErr)
+
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) && remaining == "0" {
+		reset := "unknown"
+		if epoch, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			resetAt := time.Unix(epoch, 0)
+			reset = fmt.Sprintf("%s (in %s)", resetAt.Format("15:04:05"), time.Until(resetAt).Round(time.Second))
+		}
+		hint := "set GITHUB_TOKEN to raise the limit"
+		if os.Getenv("GITHUB_TOKEN") != "" {
+			hint = "the limit applies to the GITHUB_TOKEN in use"
+		}
+		return fmt.Errorf("GitHub API rate limit exceeded (X-RateLimit-Remaining: 0, resets at %s); %s", reset, hint)
+	}
+
+	if apiErr.Message != "" {
+		return fmt.Errorf("GitHub API request failed: %s: %s", resp.Status, apiErr.Message)
+	}
+	return fmt.Errorf("GitHub API request failed: %s", resp.Status)
+}
@@ -0,0 +1,58 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchJSONConditional(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"body": "changelog"}`))
+	}))
+	defer server.Close()
+
+	gbeHome := t.TempDir()
+	for i := 0; i < 2; i++ {
+		var release struct {
+			Body string `json:"body"`
+		}
+		if err := fetchJSON(gbeHome, server.URL, &release); err != nil {
+			t.Fatalf("fetchJSON failed on request %d: %v", i+1, err)
+		}
+		if release.Body != "changelog" {
+			t.Errorf("Expected body %q on request %d, got %q", "changelog", i+1, release.Body)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests)
+	}
+}
+
+func TestFetchJSONRateLimit(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+	}))
+	defer server.Close()
+
+	var release struct{}
+	err := fetchJSON(t.TempDir(), server.URL, &release)
+	if err == nil {
+		t.Fatalf("fetchJSON was expected to fail but succeeded")
+	}
+	if !strings.Contains(err.Error(), "rate limit exceeded") || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Errorf("Expected rate limit error mentioning GITHUB_TOKEN, got %v", err)
+	}
+}
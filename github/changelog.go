@@ -0,0 +1,180 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gbe_fork_helper/config"
+
+	"github.com/charmbracelet/glamour"
+	"golang.org/x/term"
+)
+
+// maxReleasePages bounds how many pages of releases are fetched when looking
+// for the installed release.
+const maxReleasePages = 5
+
+// releasesPerPage is the number of releases requested per page.
+const releasesPerPage = 100
+
+// errToBeforeFrom is returned when the last release to show is older than
+// the release to start after.
+var errToBeforeFrom = errors.New("--to is older than --from")
+
+// renderMarkdown renders release notes for the terminal, falling back to the
+// raw markdown if rendering fails.
+func renderMarkdown(body string) string {
+	// glamour.WithAutoStyle() automatically detects the current terminal's dark/light mode
+	renderer, err := glamour.NewTermRenderer(
+		glamour.WithAutoStyle(),
+	)
+	if err != nil {
+		log.Printf("WARN: Failed to create markdown renderer: %v", err)
+		return body
+	}
+	renderedText, err := renderer.Render(body)
+	if err != nil {
+		log.Printf("WARN: Failed to render markdown: %v", err)
+		return body
+	}
+	return renderedText
+}
+
+// installedRelease returns the tag of the installed release, and the recorded
+// update timestamp for installs that predate the version file.
+func installedRelease(gbeHome string) (tag, timestamp string) {
+	if data, err := os.ReadFile(filepath.Join(gbeHome, versionFileName)); err == nil {
+		tag = strings.TrimSpace(string(data))
+	}
+	if data, err := os.ReadFile(filepath.Join(gbeHome, ".gbe_timestamp")); err == nil {
+		timestamp = string(data)
+	}
+	return tag, timestamp
+}
+
+// Changelog prints the notes of every release newer than from, up to and
+// including to. An empty from uses the installed release and an empty to uses
+// the latest release. Format is "markdown", "json" or "" to render with
+// glamour on terminals and print markdown otherwise.
+func Changelog(from, to, format string) error {
+	switch format {
+	case "":
+		format = "markdown"
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			format = "rendered"
+		}
+	case "markdown", "json":
+	default:
+		return fmt.Errorf("invalid format: '%s'. Valid formats: markdown, json", format)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	gbeHome := filepath.Join(homeDir, config.GbeDir)
+
+	timestamp, installed := "", from == ""
+	if installed {
+		from, timestamp = installedRelease(gbeHome)
+	}
+	releases, err := findReleases(gbeHome, config.GithubReleasesURL, from, timestamp, to)
+	if errors.Is(err, errToBeforeFrom) && installed {
+		return fmt.Errorf("--to release '%s' is older than the installed release. Use --from to show older releases", to)
+	}
+	if err != nil {
+		return err
+	}
+	if len(releases) == 0 {
+		log.Println("SUCCESS: No new releases.")
+		return nil
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(releases)
+	}
+	for _, r := range releases {
+		notes := releaseNotes(r)
+		if format == "rendered" {
+			notes = renderMarkdown(notes)
+		}
+		fmt.Println(notes)
+	}
+	return nil
+}
+
+// findReleases pages through the releases at url, newest first, and returns
+// those newer than from, up to and including to. Without from, the release
+// updated at timestamp is used. When neither is found, every release up to
+// to is returned.
+func findReleases(gbeHome, url, from, timestamp, to string) ([]config.Release, error) {
+	isFrom := func(r config.Release) bool {
+		if from != "" {
+			return r.TagName == from
+		}
+		return timestamp != "" && r.UpdatedAt.String() == timestamp
+	}
+
+	var releases []config.Release
+	foundTo, foundFrom := to == "", false
+pages:
+	for page := 1; page <= maxReleasePages; page++ {
+		var batch []config.Release
+		if err := fetchJSON(gbeHome, fmt.Sprintf("%s?per_page=%d&page=%d", url, releasesPerPage, page), &batch); err != nil {
+			return nil, err
+		}
+		for _, r := range batch {
+			if !foundFrom && isFrom(r) {
+				foundFrom = true
+			}
+			if !foundTo && r.TagName == to {
+				if foundFrom && !isFrom(r) {
+					return nil, errToBeforeFrom
+				}
+				foundTo = true
+			}
+			if foundFrom && foundTo {
+				break pages
+			}
+			if foundTo {
+				releases = append(releases, r)
+			}
+		}
+		if len(batch) < releasesPerPage {
+			break
+		}
+	}
+
+	if !foundTo {
+		return nil, fmt.Errorf("release '%s' not found", to)
+	}
+	if !foundFrom {
+		if from == "" && timestamp == "" {
+			log.Println("WARN: No installed release recorded. Showing all releases.")
+		} else {
+			log.Printf("WARN: Installed release '%s' not found. Showing all releases.", from)
+		}
+	}
+	return releases, nil
+}
+
+// releaseTitle returns the display name of a release.
+func releaseTitle(r config.Release) string {
+	if r.Name != "" && r.Name != r.TagName {
+		return fmt.Sprintf("%s - %s", r.TagName, r.Name)
+	}
+	return r.TagName
+}
+
+// releaseNotes returns the markdown notes of a release under a heading with
+// its title and publication date.
+func releaseNotes(r config.Release) string {
+	return fmt.Sprintf("# %s (%s)\n\n%s\n", releaseTitle(r), r.PublishedAt.Format("2006-01-02"), r.Body)
+}
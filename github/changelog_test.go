@@ -0,0 +1,109 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gbe_fork_helper/config"
+)
+
+// sampleReleaseBody is the kind of notes published with a gbe_fork release.
+const sampleReleaseBody = "## Changes\r\n* fix crash on startup\r\n* add `steam_deck` option\r\n"
+
+func TestReleaseNotes(t *testing.T) {
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		tagName, name, expected string
+	}{
+		{"release-2024_05_01", "", "# release-2024_05_01 (2024-05-01)\n\n" + sampleReleaseBody + "\n"},
+		{"release-2024_05_01", "release-2024_05_01", "# release-2024_05_01 (2024-05-01)\n\n" + sampleReleaseBody + "\n"},
+		{"release-2024_05_01", "Overlay fixes", "# release-2024_05_01 - Overlay fixes (2024-05-01)\n\n" + sampleReleaseBody + "\n"},
+	} {
+		r := config.Release{TagName: test.tagName, Name: test.name, PublishedAt: published, Body: sampleReleaseBody}
+		if got := releaseNotes(r); got != test.expected {
+			t.Errorf("Expected %q for name %q, got %q", test.expected, test.name, got)
+		}
+	}
+}
+
+func TestFindReleases(t *testing.T) {
+	// Serve releases v150 down to v1, two pages of 100 at most
+	var pages []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pages = append(pages, page)
+		var batch []config.Release
+		for n := 150 - (page-1)*releasesPerPage; n > 0 && n > 150-page*releasesPerPage; n-- {
+			batch = append(batch, config.Release{TagName: fmt.Sprintf("v%d", n), UpdatedAt: time.Unix(int64(n), 0).UTC()})
+		}
+		json.NewEncoder(w).Encode(batch)
+	}))
+	defer server.Close()
+
+	check := func(releases []config.Release, newest, oldest int) {
+		t.Helper()
+		if len(releases) != newest-oldest+1 {
+			t.Fatalf("Expected %d releases, got %d", newest-oldest+1, len(releases))
+		}
+		if first, last := releases[0].TagName, releases[len(releases)-1].TagName; first != fmt.Sprintf("v%d", newest) || last != fmt.Sprintf("v%d", oldest) {
+			t.Errorf("Expected v%d to v%d, got %s to %s", newest, oldest, first, last)
+		}
+	}
+
+	// Test the releases since an installed release on the first page
+	releases, err := findReleases(t.TempDir(), server.URL, "v120", "", "")
+	if err != nil {
+		t.Fatalf("findReleases failed: %v", err)
+	}
+	check(releases, 150, 121)
+	if len(pages) != 1 {
+		t.Errorf("Expected only the first page to be fetched, got pages %v", pages)
+	}
+
+	// Test a range spanning both pages
+	releases, err = findReleases(t.TempDir(), server.URL, "v30", "", "v60")
+	if err != nil {
+		t.Fatalf("findReleases failed: %v", err)
+	}
+	check(releases, 60, 31)
+
+	// Test an installed release recorded by its update timestamp
+	releases, err = findReleases(t.TempDir(), server.URL, "", time.Unix(140, 0).UTC().String(), "")
+	if err != nil {
+		t.Fatalf("findReleases failed: %v", err)
+	}
+	check(releases, 150, 141)
+
+	// Test without an installed release
+	releases, err = findReleases(t.TempDir(), server.URL, "", "", "")
+	if err != nil {
+		t.Fatalf("findReleases failed: %v", err)
+	}
+	check(releases, 150, 1)
+
+	// Test the installed release itself as the last release
+	releases, err = findReleases(t.TempDir(), server.URL, "v40", "", "v40")
+	if err != nil {
+		t.Fatalf("findReleases failed: %v", err)
+	}
+	if len(releases) != 0 {
+		t.Errorf("Expected no releases, got %d", len(releases))
+	}
+
+	// Test a last release older than the installed one
+	_, err = findReleases(t.TempDir(), server.URL, "v120", "", "v10")
+	if !errors.Is(err, errToBeforeFrom) {
+		t.Errorf("Expected %v, got %v", errToBeforeFrom, err)
+	}
+
+	// Test an unknown last release
+	if _, err := findReleases(t.TempDir(), server.URL, "v120", "", "v999"); err == nil {
+		t.Errorf("findReleases was expected to fail for an unknown release but succeeded")
+	}
+}
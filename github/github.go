@@ -1,22 +1,106 @@
 package github
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"gbe_fork_helper/config"
 	"gbe_fork_helper/util"
-
-	"github.com/charmbracelet/glamour"
 )
 
+// versionFileName records the tag of the installed release inside the GBE directory.
+const versionFileName = ".gbe_version"
+
+// asset is a release asset selected for download.
+type asset struct {
+	name, suffix, subdir, format string
+	optional                     bool
+}
+
+// selectAssets resolves the requested platforms and extras to the set of
+// release assets to download. Platforms sharing a release subdir (win64 and
+// win32) only produce a single download.
+func selectAssets(platforms, extras []string) ([]asset, error) {
+	var selected []asset
+	seen := make(map[string]bool)
+
+	for _, platform := range platforms {
+		platformCfg, ok := config.PlatformConfig[platform]
+		if !ok {
+			var validPlatforms []string
+			for p := range config.PlatformConfig {
+				validPlatforms = append(validPlatforms, p)
+			}
+			sort.Strings(validPlatforms)
+			return nil, fmt.Errorf("invalid platform: '%s'. Valid platforms: %s", platform, strings.Join(validPlatforms, ", "))
+		}
+		if seen[platformCfg.Subdir] {
+			continue
+		}
+		seen[platformCfg.Subdir] = true
+		selected = append(selected, asset{
+			name:   platformCfg.Subdir,
+			suffix: platformCfg.Asset,
+			subdir: platformCfg.Subdir,
+			format: platformCfg.Format,
+		})
+	}
+
+	for _, extra := range extras {
+		extraCfg, ok := config.ExtraAssets[extra]
+		if !ok {
+			var validExtras []string
+			for e := range config.ExtraAssets {
+				validExtras = append(validExtras, e)
+			}
+			sort.Strings(validExtras)
+			return nil, fmt.Errorf("invalid extra asset: '%s'. Valid extras: %s", extra, strings.Join(validExtras, ", "))
+		}
+		if seen[extraCfg.Subdir] {
+			continue
+		}
+		seen[extraCfg.Subdir] = true
+		selected = append(selected, asset{
+			name:     extra,
+			suffix:   extraCfg.Asset,
+			subdir:   extraCfg.Subdir,
+			format:   extraCfg.Format,
+			optional: true,
+		})
+	}
+
+	return selected, nil
+}
+
+// installed reports whether every selected asset has been extracted.
+func installed(gbeHome string, assets []asset) bool {
+	for _, a := range assets {
+		if _, err := os.Stat(filepath.Join(gbeHome, a.subdir)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 // updateGBE fetches and extracts the latest GBE fork.
-func UpdateGBE() error {
+// Only the assets for the given platforms and extras are downloaded; an
+// empty platform list selects every known platform.
+func UpdateGBE(platforms, extras []string, opts util.DownloadOptions) error {
+	if len(platforms) == 0 {
+		for p := range config.PlatformConfig {
+			platforms = append(platforms, p)
+		}
+		sort.Strings(platforms)
+	}
+	assets, err := selectAssets(platforms, extras)
+	if err != nil {
+		return err
+	}
+
 	log.Println("INFO: Fetching latest GBE fork from GitHub...")
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -25,83 +109,58 @@ func UpdateGBE() error {
 	gbeHome := filepath.Join(homeDir, config.GbeDir)
 	timestampFile := filepath.Join(gbeHome, ".gbe_timestamp")
 
-	resp, err := http.Get(config.GithubAPIURL)
-	if err != nil {
-		return fmt.Errorf("failed to fetch release information: %w", err)
-	}
-	defer resp.Body.Close()
-
 	var release config.Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return fmt.Errorf("failed to decode JSON: %w", err)
+	if err := fetchJSON(gbeHome, config.GithubAPIURL, &release); err != nil {
+		return err
 	}
 
 	if _, err := os.Stat(timestampFile); err == nil {
 		timestamp, err := os.ReadFile(timestampFile)
-		if err == nil && string(timestamp) == release.UpdatedAt.String() {
+		if err == nil && string(timestamp) == release.UpdatedAt.String() && installed(gbeHome, assets) {
 			log.Println("SUCCESS: GBE fork is already up-to-date.")
 			return nil
 		}
 	}
-	// Create a new renderer with the desired style
-	// glamour.WithAutoStyle() automatically detects the current terminal's dark/light mode
-	renderer, _ := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-	)
-
-	// Render the markdown text
-	renderedText, err := renderer.Render(release.Body)
-	if err != nil {
-		fmt.Println("Error rendering markdown:", err)
-		return nil
-	}
-
-	// Print the rendered output to the command line
-	fmt.Println(renderedText)
+	// Print the release notes to the command line
+	fmt.Println(renderMarkdown(release.Body))
 
 	if err := os.MkdirAll(gbeHome, 0755); err != nil {
 		return fmt.Errorf("failed to create directory %s: %w", gbeHome, err)
 	}
 
-	// Download and extract Linux release
-	linuxURL := ""
-	for _, asset := range release.Assets {
-		if strings.HasSuffix(asset.Name, "linux-release.tar.bz2") {
-			linuxURL = asset.BrowserDownloadURL
-			break
+	for _, a := range assets {
+		url := ""
+		for _, releaseAsset := range release.Assets {
+			if strings.HasSuffix(releaseAsset.Name, a.suffix) {
+				url = releaseAsset.BrowserDownloadURL
+				break
+			}
 		}
-	}
-	if linuxURL == "" {
-		return fmt.Errorf("failed to find Linux download URL")
-	}
-
-	log.Println("INFO: Downloading Linux release...")
-	if err := util.DownloadAndExtract(linuxURL, filepath.Join(gbeHome, "linux_release"), "tar.bz2"); err != nil {
-		return fmt.Errorf("failed to update Linux release: %w", err)
-	}
-	log.Println("SUCCESS: Linux release extracted.")
-
-	// Download and extract Windows release
-	winURL := ""
-	for _, asset := range release.Assets {
-		if strings.HasSuffix(asset.Name, "win-release.7z") {
-			winURL = asset.BrowserDownloadURL
-			break
+		if url == "" {
+			if a.optional {
+				log.Printf("WARN: Release has no '%s' asset. Skipping.", a.suffix)
+				continue
+			}
+			return fmt.Errorf("failed to find download URL for '%s'", a.suffix)
 		}
-	}
-	if winURL == "" {
-		return fmt.Errorf("failed to find Windows download URL")
-	}
 
-	log.Println("INFO: Downloading Windows release...")
-	if err := util.DownloadAndExtract(winURL, filepath.Join(gbeHome, "win_release"), "7z"); err != nil {
-		return fmt.Errorf("failed to update Windows release: %w", err)
+		log.Printf("INFO: Downloading %s...", a.suffix)
+		if err := util.DownloadAndExtract(url, filepath.Join(gbeHome, a.subdir), a.format, opts); err != nil {
+			if a.optional {
+				log.Printf("WARN: Failed to update %s: %v", a.name, err)
+				continue
+			}
+			return fmt.Errorf("failed to update %s: %w", a.name, err)
+		}
+		log.Printf("SUCCESS: %s extracted.", a.suffix)
 	}
-	log.Println("SUCCESS: Windows release extracted.")
 
 	if err := os.WriteFile(timestampFile, []byte(release.UpdatedAt.String()), 0644); err != nil {
 		return fmt.Errorf("failed to write timestamp file: %w", err)
 	}
+	if err := os.WriteFile(filepath.Join(gbeHome, versionFileName), []byte(release.TagName), 0644); err != nil {
+		return fmt.Errorf("failed to write version file: %w", err)
+	}
 
 	log.Println("SUCCESS: GBE fork updated successfully.")
 	return nil
@@ -0,0 +1,74 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSelectAssets(t *testing.T) {
+	for _, test := range []struct {
+		platforms, extras []string
+		expected          []string
+	}{
+		{[]string{"linux"}, nil, []string{"linux-release.tar.bz2"}},
+		// win64 and win32 share the Windows release
+		{[]string{"win64", "win32"}, nil, []string{"win-release.7z"}},
+		{[]string{"linux", "win64"}, []string{"gen_emu_config-linux"}, []string{"linux-release.tar.bz2", "win-release.7z", "gen_emu_config-linux.tar.bz2"}},
+		{nil, []string{"win-debug", "win-debug"}, []string{"win-debug.7z"}},
+	} {
+		assets, err := selectAssets(test.platforms, test.extras)
+		if err != nil {
+			t.Fatalf("selectAssets failed for %v %v: %v", test.platforms, test.extras, err)
+		}
+		var got []string
+		for _, a := range assets {
+			got = append(got, a.suffix)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Expected %v for %v %v, got %v", test.expected, test.platforms, test.extras, got)
+		}
+	}
+
+	// Test that only extras are optional
+	assets, err := selectAssets([]string{"linux"}, []string{"linux-debug"})
+	if err != nil {
+		t.Fatalf("selectAssets failed: %v", err)
+	}
+	if assets[0].optional || !assets[1].optional {
+		t.Errorf("Expected only the extra to be optional, got %+v", assets)
+	}
+
+	// Test unknown platforms and extras
+	if _, err := selectAssets([]string{"macos"}, nil); err == nil {
+		t.Errorf("selectAssets was expected to fail for an unknown platform but succeeded")
+	}
+	if _, err := selectAssets(nil, []string{"tools"}); err == nil {
+		t.Errorf("selectAssets was expected to fail for an unknown extra but succeeded")
+	}
+}
+
+func TestInstalled(t *testing.T) {
+	gbeHome := t.TempDir()
+	assets, err := selectAssets([]string{"linux"}, []string{"linux-debug"})
+	if err != nil {
+		t.Fatalf("selectAssets failed: %v", err)
+	}
+
+	// Test with only the platform extracted
+	if err := os.Mkdir(filepath.Join(gbeHome, "linux_release"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if installed(gbeHome, assets) {
+		t.Errorf("Expected the assets not to be installed without linux_debug")
+	}
+
+	// Test with every asset extracted
+	if err := os.Mkdir(filepath.Join(gbeHome, "linux_debug"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if !installed(gbeHome, assets) {
+		t.Errorf("Expected the assets to be installed")
+	}
+}
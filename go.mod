@@ -8,6 +8,7 @@ toolchain go1.24.6
 require (
 	github.com/charmbracelet/glamour v0.10.0
 	golang.org/x/crypto v0.31.0
+	golang.org/x/term v0.31.0
 )
 
 require (
@@ -34,6 +35,5 @@ require (
 	github.com/yuin/goldmark-emoji v1.0.5 // indirect
 	golang.org/x/net v0.33.0 // indirect
 	golang.org/x/sys v0.32.0 // indirect
-	golang.org/x/term v0.31.0 // indirect
 	golang.org/x/text v0.24.0 // indirect
 )
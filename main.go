@@ -2,12 +2,18 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
+	"gbe_fork_helper/config"
 	"gbe_fork_helper/gbe"
 	"gbe_fork_helper/github"
+	"gbe_fork_helper/steam"
+	"gbe_fork_helper/util"
 )
 
 // Version of the gbe_fork_helper application
@@ -34,13 +40,39 @@ func main() {
 
 	switch command {
 	case "apply":
+		err = runApply(args[1:])
+	case "coldclient":
 		if len(args) < 3 {
-			err = fmt.Errorf("Usage: %s apply <platform> <appid>", os.Args[0])
+			err = fmt.Errorf("Usage: %s coldclient <exe> <appid>", os.Args[0])
 		} else {
-			err = gbe.ApplyGBE(args[1], args[2])
+			err = gbe.SetupColdClient(args[1], args[2])
 		}
+	case "config":
+		err = runConfig(args[1:])
+	case "controller":
+		err = runController(args[1:])
+	case "mods":
+		err = runMods(args[1:])
+	case "overlay":
+		err = runOverlay(args[1:])
+	case "profile":
+		err = runProfile(args[1:])
+	case "proton":
+		err = listProton()
+	case "run":
+		err = runRun(args[1:])
+	case "steam-launch":
+		err = runSteamLaunch(args[1:])
 	case "update":
-		err = github.UpdateGBE()
+		err = runUpdate(args[1:])
+	case "changelog":
+		err = runChangelog(args[1:])
+	case "validate":
+		path := "."
+		if len(args) > 1 {
+			path = args[1]
+		}
+		err = gbe.ValidateSettings(path)
 	case "version":
 		fmt.Println(GetVersion())
 	default:
@@ -53,10 +85,370 @@ func main() {
 	}
 }
 
+// runApply parses the apply command's options and applies GBE in the current directory.
+func runApply(args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	var opts gbe.ApplyOptions
+	fs.StringVar(&opts.Flavour, "flavour", "", "build flavour: regular, experimental or debug")
+	wineFlags(fs, &opts.Wine)
+	fs.Var(optionalBool{&opts.UnlockAll}, "unlock-all", "report every DLC as owned (remembered)")
+	fs.Func("app-path", "DLC install folder as <appid>=<path>, may be repeated (remembered)", func(value string) error {
+		id, path, ok := strings.Cut(value, "=")
+		if !ok || id == "" {
+			return fmt.Errorf("invalid app path '%s', expected <appid>=<path>", value)
+		}
+		if opts.AppPaths == nil {
+			opts.AppPaths = make(map[string]string)
+		}
+		opts.AppPaths[id] = path
+		return nil
+	})
+	mainFlags(fs, &opts.Main)
+	fs.Var(optionalBool{&opts.Template}, "template", "apply the steam_settings template (remembered)")
+	fs.Func("installed-apps", "comma-separated AppIDs to report as installed besides the Steam libraries', empty to clear (remembered)", func(value string) error {
+		opts.InstalledApps = append([]string{}, splitList(value)...)
+		return nil
+	})
+	positional, _, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		return fmt.Errorf("Usage: %s apply <platform> <appid> [options]", os.Args[0])
+	}
+	return gbe.ApplyGBE(positional[0], positional[1], opts)
+}
+
+// runRun parses the run command's options and launches the game.
+func runRun(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	var opts gbe.RunOptions
+	fs.StringVar(&opts.Exe, "exe", "", "game executable, relative to the game directory (remembered for later runs)")
+	wineFlags(fs, &opts.Wine)
+	fs.BoolVar(&opts.Wrap, "wrap", false, "run the arguments after -- as the full command, e.g. Steam's %%command%%")
+	positional, gameArgs, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 1 {
+		return fmt.Errorf("Usage: %s run <path|appid> [options] [-- args]", os.Args[0])
+	}
+	return gbe.RunGame(positional[0], gameArgs, opts)
+}
+
+// runSteamLaunch parses the steam-launch command's options and edits the game's Steam launch options.
+func runSteamLaunch(args []string) error {
+	fs := flag.NewFlagSet("steam-launch", flag.ContinueOnError)
+	var opts gbe.SteamLaunchOptions
+	fs.StringVar(&opts.Mode, "mode", "run", "launch through the run command (run) or the ColdClientLoader (coldclient)")
+	fs.StringVar(&opts.User, "user", "", "Steam account ID to edit (default: most recent user)")
+	fs.BoolVar(&opts.Force, "force", false, "edit localconfig.vdf even while Steam is running")
+	remove := fs.Bool("remove", false, "restore the launch options replaced earlier")
+	positional, _, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 1 {
+		return fmt.Errorf("Usage: %s steam-launch <appid> [options]", os.Args[0])
+	}
+	if *remove {
+		return gbe.RemoveSteamLaunch(positional[0], opts)
+	}
+	return gbe.SetSteamLaunch(positional[0], opts)
+}
+
+// wineFlags registers the options selecting how Windows executables are run.
+func wineFlags(fs *flag.FlagSet, cfg *gbe.WineConfig) {
+	fs.StringVar(&cfg.Proton, "proton", "", "Proton install name or path for Windows executables (remembered)")
+	fs.StringVar(&cfg.Wine, "wine", "", "wine executable for Windows executables (remembered)")
+	fs.StringVar(&cfg.Prefix, "prefix", "", "Wine prefix or Proton compatdata directory (remembered)")
+}
+
+// mainFlags registers the options written to configs.main.ini and custom_broadcasts.txt.
+func mainFlags(fs *flag.FlagSet, settings *config.MainSettings) {
+	fs.Var(optionalBool{&settings.Offline}, "offline", "pretend Steam is offline (remembered)")
+	fs.Var(optionalBool{&settings.DisableNetworking}, "disable-networking", "turn off the emulator's networking (remembered)")
+	fs.Var(optionalBool{&settings.DisableLANOnly}, "disable-lan-only", "allow peers outside the LAN (remembered)")
+	fs.IntVar(&settings.ListenPort, "listen-port", 0, "port to listen on for peers (remembered)")
+	fs.Var(optionalBool{&settings.EnableAccountAvatar}, "account-avatar", "load the account avatar (remembered)")
+	fs.Var(optionalBool{&settings.SteamDeck}, "steam-deck", "report running on a Steam Deck (remembered)")
+	fs.Var(optionalBool{&settings.AchievementsBypass}, "achievements-bypass", "allow unlocking achievements regardless of stats (remembered)")
+	fs.Func("broadcasts", "comma-separated peer IPs for custom_broadcasts.txt, empty for the profile's (remembered)", func(value string) error {
+		settings.Broadcasts = append([]string{}, splitList(value)...)
+		return nil
+	})
+	fs.Func("main", "other configs.main.ini key as <section>.<key>=<value>, may be repeated (remembered)", sectionKeyFlag(&settings.Extra))
+}
+
+// runConfig parses the config command's options and generates a game's configuration.
+func runConfig(args []string) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	var opts gbe.GenerateOptions
+	fs.StringVar(&opts.Dir, "dir", "", "directory holding the steam_api file (default: from the manifest, else the current directory)")
+	fs.StringVar(&opts.ControllerFile, "controller-file", "", "Steam Input configuration VDF (default: found in the Steam install)")
+	sections := fs.String("sections", "", "comma-separated sections to generate (default: all)")
+	positional, _, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 || positional[0] != "generate" {
+		return fmt.Errorf("Usage: %s config generate <appid> [options]", os.Args[0])
+	}
+	opts.Sections = splitList(*sections)
+	return gbe.GenerateConfig(positional[1], opts)
+}
+
+// runController parses the controller command's options and imports the game's Steam Input configuration.
+func runController(args []string) error {
+	fs := flag.NewFlagSet("controller", flag.ContinueOnError)
+	file := fs.String("file", "", "Steam Input configuration VDF (default: found in the Steam install)")
+	if _, _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return gbe.ImportController(*file)
+}
+
+// runMods parses the mods command's options and imports mods into the game in the current directory.
+func runMods(args []string) error {
+	fs := flag.NewFlagSet("mods", flag.ContinueOnError)
+	var opts gbe.ModsOptions
+	fs.BoolVar(&opts.Workshop, "workshop", false, "import every item Steam downloaded for the game")
+	fs.BoolVar(&opts.Link, "link", false, "point mods.json at the mod folders instead of copying them")
+	fs.BoolVar(&opts.Offline, "offline", false, "do not fetch titles and preview images")
+	sources, _, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	return gbe.ImportMods(sources, opts)
+}
+
+// runOverlay parses the overlay command's options and updates the overlay settings of every game.
+func runOverlay(args []string) error {
+	fs := flag.NewFlagSet("overlay", flag.ContinueOnError)
+	var changes config.OverlaySettings
+	fs.Var(optionalBool{&changes.DisableAchievementNotification}, "disable-achievement-notification", "hide achievement unlock notifications")
+	fs.Var(optionalBool{&changes.DisableFriendNotification}, "disable-friend-notification", "hide friend notifications")
+	fs.Var(optionalBool{&changes.DisableAchievementProgress}, "disable-achievement-progress", "hide achievement progress notifications")
+	fs.Var(optionalBool{&changes.DisableWarnings}, "disable-warnings", "hide every overlay warning")
+	fs.IntVar(&changes.HookDelay, "hook-delay", 0, "seconds to wait before hooking the renderer")
+	fs.StringVar(&changes.Font, "font", "", "font file from the shared overlay fonts directory")
+	fs.Func("set", "other configs.overlay.ini key as <section>.<key>=<value>, may be repeated", sectionKeyFlag(&changes.Extra))
+	positional, _, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 1 {
+		return fmt.Errorf("Usage: %s overlay enable|disable|configure [options]", os.Args[0])
+	}
+
+	enabled := positional[0] == "enable"
+	switch positional[0] {
+	case "enable", "disable":
+		changes.Enabled = &enabled
+	case "configure":
+	default:
+		return fmt.Errorf("invalid overlay action: '%s'. Valid actions: enable, disable, configure", positional[0])
+	}
+	return gbe.ConfigureOverlay(changes)
+}
+
+// runProfile parses the profile command's options and updates the user's emulator profile.
+func runProfile(args []string) error {
+	fs := flag.NewFlagSet("profile", flag.ContinueOnError)
+	var opts gbe.AvatarOptions
+	fs.BoolVar(&opts.Game, "game", false, "set the avatar of the game in the current directory only")
+	positional, _, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 || positional[0] != "avatar" {
+		return fmt.Errorf("Usage: %s profile avatar <image> [--game]", os.Args[0])
+	}
+	return gbe.SetAvatar(positional[1], opts)
+}
+
+// sectionKeyFlag returns a flag function setting INI keys given as <section>.<key>=<value>.
+func sectionKeyFlag(sections *map[string]map[string]string) func(string) error {
+	return func(value string) error {
+		name, v, ok := strings.Cut(value, "=")
+		dot := strings.LastIndex(name, ".")
+		if !ok || dot <= 0 || dot == len(name)-1 {
+			return fmt.Errorf("invalid setting '%s', expected <section>.<key>=<value>", value)
+		}
+		if *sections == nil {
+			*sections = make(map[string]map[string]string)
+		}
+		section := name[:dot]
+		if (*sections)[section] == nil {
+			(*sections)[section] = make(map[string]string)
+		}
+		(*sections)[section][name[dot+1:]] = v
+		return nil
+	}
+}
+
+// optionalBool is a boolean flag that stays nil unless given, so that an
+// unset option can fall back to a remembered or default value.
+type optionalBool struct {
+	value **bool
+}
+
+func (b optionalBool) String() string {
+	if b.value == nil || *b.value == nil {
+		return ""
+	}
+	return strconv.FormatBool(**b.value)
+}
+
+func (b optionalBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*b.value = &v
+	return nil
+}
+
+func (b optionalBool) IsBoolFlag() bool { return true }
+
+// listProton prints the Proton installs found in the Steam installation.
+func listProton() error {
+	installs, err := steam.FindProtonInstalls()
+	if err != nil {
+		return err
+	}
+	if len(installs) == 0 {
+		log.Println("WARN: No Proton installs found.")
+		return nil
+	}
+	for _, install := range installs {
+		fmt.Printf("%s\t%s\n", install.Name, install.Path)
+	}
+	return nil
+}
+
+// runUpdate parses the update command's options and runs the updater.
+func runUpdate(args []string) error {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	platforms := fs.String("platforms", "", "comma-separated platforms to download (default: all)")
+	extras := fs.String("extras", "", "comma-separated extra assets to download")
+	limitRate := fs.String("limit-rate", "", "maximum download speed, e.g. 500K or 2M")
+	if _, _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var opts util.DownloadOptions
+	if *limitRate != "" {
+		rate, err := util.ParseByteSize(*limitRate)
+		if err != nil {
+			return err
+		}
+		opts.RateLimit = rate
+	}
+	return github.UpdateGBE(splitList(*platforms), splitList(*extras), opts)
+}
+
+// runChangelog parses the changelog command's options and prints the release notes.
+func runChangelog(args []string) error {
+	fs := flag.NewFlagSet("changelog", flag.ContinueOnError)
+	from := fs.String("from", "", "release tag to start after (default: installed release)")
+	to := fs.String("to", "", "last release tag to include (default: latest release)")
+	format := fs.String("format", "", "output format: markdown or json (default: rendered on terminals)")
+	if _, _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return github.Changelog(*from, *to, *format)
+}
+
+// parseFlags parses fs from args, allowing flags to appear between positional
+// arguments. It returns the positional arguments and everything after a
+// literal "--", which is left unparsed.
+func parseFlags(fs *flag.FlagSet, args []string) (positional, passthrough []string, err error) {
+	for i, arg := range args {
+		if arg == "--" {
+			args, passthrough = args[:i], args[i+1:]
+			break
+		}
+	}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, passthrough, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// splitList splits a comma-separated option value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func printUsage() {
 	fmt.Println("Usage: gbe_fork_helper <command> [options]")
 	fmt.Println("Commands:")
 	fmt.Println("  apply <platform> <appid> - Apply GBE to Steam API files and configure DLCs")
+	fmt.Println("      --flavour <flavour>  - Build flavour: regular, experimental or debug (default: last used, else experimental)")
+	fmt.Println("      --proton <name>      - Run the Windows interface generator through this Proton install (remembered)")
+	fmt.Println("      --wine <path>        - Run the Windows interface generator through this wine (remembered)")
+	fmt.Println("      --prefix <dir>       - Wine prefix or Proton compatdata directory (remembered)")
+	fmt.Println("      --unlock-all[=false] - Report every DLC as owned (remembered, default: profile's unlock_all_dlcs)")
+	fmt.Println("      --app-path <id=path> - DLC install folder relative to steam_api, may be repeated; empty path removes (remembered)")
+	fmt.Println("      --offline, --disable-networking, --disable-lan-only[=false] - Emulator connectivity (remembered)")
+	fmt.Println("      --listen-port <port> - Port to listen on for peers (remembered)")
+	fmt.Println("      --broadcasts <list>  - Comma-separated peer IPs for custom_broadcasts.txt (remembered)")
+	fmt.Println("      --account-avatar, --steam-deck, --achievements-bypass[=false] - Emulator behaviour (remembered)")
+	fmt.Println("      --main <section.key=value> - Any other configs.main.ini key, may be repeated (remembered)")
+	fmt.Println("      --installed-apps <list> - AppIDs reported as installed besides the Steam libraries' (remembered)")
+	fmt.Println("      --template[=false]   - Apply the steam_settings template in ~/.local/share/gbe_fork (remembered, default: true)")
+	fmt.Println("  coldclient <exe> <appid> - Set up the steamclient loader (ColdClientLoader) for a Windows game")
+	fmt.Println("  run <path|appid> [-- args] - Launch a patched game with the emulator's environment")
+	fmt.Println("      --exe <path>         - Game executable, relative to the game directory (remembered)")
+	fmt.Println("      --proton, --wine, --prefix - Run Windows games through Proton or Wine, as for apply")
+	fmt.Printf("      --wrap               - Run the arguments after -- as the full command, e.g. Steam's %%command%%\n")
+	fmt.Println("  config generate <appid>  - Generate the complete steam_settings of a game in one pass")
+	fmt.Println("      --dir <dir>          - Directory holding the steam_api file (default: from the manifest, else current)")
+	fmt.Println("      --sections <list>    - Comma-separated sections: dlcs, achievements, images, stats, items, depots,")
+	fmt.Println("                             languages, leaderboards, controller, settings (default: all)")
+	fmt.Println("      --controller-file <vdf> - Steam Input configuration to convert (default: found in the Steam install)")
+	fmt.Println("  controller               - Import the Steam Input configuration of the game in the current directory")
+	fmt.Println("      --file <vdf>         - Configuration to convert (default: found in the Steam install)")
+	fmt.Println("  mods [<path>|<id>=<path>...] - Import workshop mod folders into the game in the current directory")
+	fmt.Println("      --workshop           - Import every item in Steam's workshop/content/<appid>")
+	fmt.Println("      --link               - Point mods.json at the mod folders instead of copying them")
+	fmt.Println("      --offline            - Do not fetch titles and preview images")
+	fmt.Println("  overlay enable|disable|configure - Change the overlay settings of every game (experimental build)")
+	fmt.Println("      --disable-achievement-notification, --disable-friend-notification[=false] - Hide notifications")
+	fmt.Println("      --disable-achievement-progress, --disable-warnings[=false] - Hide progress notifications or warnings")
+	fmt.Println("      --hook-delay <sec>   - Seconds to wait before hooking the renderer")
+	fmt.Println("      --font <file>        - Font from ~/.local/share/gbe_fork/overlay/fonts")
+	fmt.Println("      --set <section.key=value> - Any other configs.overlay.ini key, may be repeated")
+	fmt.Println("  profile avatar <image>   - Set the account avatar from a PNG, JPEG or GIF image")
+	fmt.Println("      --game               - Set it for the game in the current directory only")
+	fmt.Println("  proton                   - List the Proton installs found in the Steam libraries")
+	fmt.Println("  steam-launch <appid>     - Set the game's Steam launch options to start it through the emulator")
+	fmt.Println("      --mode <mode>        - run (through the run command) or coldclient (through the ColdClientLoader)")
+	fmt.Println("      --user <id>          - Steam account ID to edit (default: most recent user)")
+	fmt.Println("      --remove             - Restore the launch options replaced earlier")
+	fmt.Println("      --force              - Edit localconfig.vdf even while Steam is running")
 	fmt.Println("  update                   - Update the GBE fork repository")
+	fmt.Println("      --platforms <list>   - Comma-separated platforms to download (default: all)")
+	fmt.Println("      --extras <list>      - Optional assets: linux-debug, win-debug, gen_emu_config-linux, gen_emu_config-win")
+	fmt.Println("      --limit-rate <size>  - Maximum download speed, e.g. 500K or 2M")
+	fmt.Println("  changelog                - Show release notes between the installed and latest release")
+	fmt.Println("      --from <tag>         - Release to start after (default: installed release)")
+	fmt.Println("      --to <tag>           - Last release to include (default: latest release)")
+	fmt.Println("      --format <format>    - Output format: markdown or json (default: rendered on terminals)")
+	fmt.Println("  validate [path]          - Check the emulator settings of a game (default: current directory)")
 	fmt.Println("  version                  - Display the application version")
 }
@@ -0,0 +1,520 @@
+package steam
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// appinfo.vdf format versions, identified by the file's magic number.
+const (
+	appInfoMagic27 = 0x07564427
+	appInfoMagic28 = 0x07564428
+	appInfoMagic29 = 0x07564429
+)
+
+// Binary KeyValues type tags.
+const (
+	kvTypeMap     = 0x00
+	kvTypeString  = 0x01
+	kvTypeInt32   = 0x02
+	kvTypeFloat32 = 0x03
+	kvTypePointer = 0x04
+	kvTypeWString = 0x05
+	kvTypeColor   = 0x06
+	kvTypeUint64  = 0x07
+	kvTypeEnd     = 0x08
+	kvTypeInt64   = 0x0a
+	kvTypeEndAlt  = 0x0b
+)
+
+// ErrAppNotFound is returned when an app is not present in the local appinfo cache.
+var ErrAppNotFound = errors.New("app not found in local appinfo cache")
+
+// appInfoEntry locates an app's binary KeyValues data in appinfo.vdf.
+type appInfoEntry struct {
+	offset, size int64
+}
+
+// AppInfo is an index over the Steam client's binary appcache/appinfo.vdf.
+// Apps are decoded on demand, since the file covers every app the client
+// knows about.
+type AppInfo struct {
+	file    *os.File
+	magic   uint32
+	strings []string
+	entries map[uint32]appInfoEntry
+}
+
+// OpenAppInfo indexes the appinfo.vdf file at path.
+func OpenAppInfo(path string) (*AppInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := indexAppInfo(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to parse '%s': %w", path, err)
+	}
+	return info, nil
+}
+
+// indexAppInfo reads the header, string table and entry offsets of appinfo.vdf.
+func indexAppInfo(f *os.File) (*AppInfo, error) {
+	r := bufio.NewReader(f)
+	var header struct {
+		Magic, Universe uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	info := &AppInfo{file: f, magic: header.Magic, entries: make(map[uint32]appInfoEntry)}
+	offset := int64(8)
+
+	switch header.Magic {
+	case appInfoMagic27, appInfoMagic28:
+	case appInfoMagic29:
+		var tableOffset int64
+		if err := binary.Read(r, binary.LittleEndian, &tableOffset); err != nil {
+			return nil, err
+		}
+		offset += 8
+		table, err := readStringTable(io.NewSectionReader(f, tableOffset, math.MaxInt64-tableOffset))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read string table: %w", err)
+		}
+		info.strings = table
+	default:
+		return nil, fmt.Errorf("unsupported appinfo.vdf version 0x%08x", header.Magic)
+	}
+
+	// Fixed fields between the size and the KeyValues data
+	headerSize := int64(4 + 4 + 8 + 20 + 4)
+	if header.Magic != appInfoMagic27 {
+		headerSize += 20
+	}
+	for {
+		var entry struct {
+			AppID, Size uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &entry.AppID); err != nil {
+			return nil, err
+		}
+		if entry.AppID == 0 {
+			return info, nil
+		}
+		if err := binary.Read(r, binary.LittleEndian, &entry.Size); err != nil {
+			return nil, err
+		}
+		offset += 8
+		if int64(entry.Size) < headerSize {
+			return nil, fmt.Errorf("invalid entry size %d for AppID %d", entry.Size, entry.AppID)
+		}
+		info.entries[entry.AppID] = appInfoEntry{offset: offset + headerSize, size: int64(entry.Size) - headerSize}
+		if _, err := r.Discard(int(entry.Size)); err != nil {
+			return nil, err
+		}
+		offset += int64(entry.Size)
+	}
+}
+
+// readStringTable reads the key string table of a v29 appinfo.vdf.
+func readStringTable(r io.Reader) ([]string, error) {
+	br := bufio.NewReader(r)
+	var count uint32
+	if err := binary.Read(br, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+	table := make([]string, 0, count)
+	for i := uint32(0); i < count; i++ {
+		s, err := br.ReadString(0)
+		if err != nil {
+			return nil, err
+		}
+		table = append(table, s[:len(s)-1])
+	}
+	return table, nil
+}
+
+// Close releases the underlying file.
+func (a *AppInfo) Close() error {
+	return a.file.Close()
+}
+
+// App returns the decoded "appinfo" section of an app, with children such as
+// common, extended, config and depots. Numeric values are stored as strings.
+func (a *AppInfo) App(appID string) (*KeyValue, error) {
+	id, err := strconv.ParseUint(appID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AppID: '%s'", appID)
+	}
+	entry, ok := a.entries[uint32(id)]
+	if !ok {
+		return nil, ErrAppNotFound
+	}
+
+	d := &kvDecoder{r: bufio.NewReader(io.NewSectionReader(a.file, entry.offset, entry.size)), strings: a.strings}
+	root := &KeyValue{Children: []*KeyValue{}}
+	if err := d.decodeMap(root); err != nil {
+		return nil, fmt.Errorf("failed to decode AppID %s: %w", appID, err)
+	}
+	if section := root.Get("appinfo"); section != nil {
+		return section, nil
+	}
+	return root, nil
+}
+
+// kvDecoder decodes binary KeyValues.
+type kvDecoder struct {
+	r *bufio.Reader
+	// strings is the key table for v29 files; keys are inline strings when nil.
+	strings []string
+}
+
+// decodeMap reads entries into parent until the end marker.
+func (d *kvDecoder) decodeMap(parent *KeyValue) error {
+	for {
+		kind, err := d.r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if kind == kvTypeEnd || kind == kvTypeEndAlt {
+			return nil
+		}
+
+		key, err := d.key()
+		if err != nil {
+			return err
+		}
+		node := &KeyValue{Key: key}
+		switch kind {
+		case kvTypeMap:
+			node.Children = []*KeyValue{}
+			if err := d.decodeMap(node); err != nil {
+				return err
+			}
+		case kvTypeString:
+			if node.Value, err = d.cstring(); err != nil {
+				return err
+			}
+		case kvTypeInt32, kvTypePointer, kvTypeColor:
+			var v int32
+			err = binary.Read(d.r, binary.LittleEndian, &v)
+			node.Value = strconv.FormatInt(int64(v), 10)
+		case kvTypeFloat32:
+			var v float32
+			err = binary.Read(d.r, binary.LittleEndian, &v)
+			node.Value = strconv.FormatFloat(float64(v), 'g', -1, 32)
+		case kvTypeUint64:
+			var v uint64
+			err = binary.Read(d.r, binary.LittleEndian, &v)
+			node.Value = strconv.FormatUint(v, 10)
+		case kvTypeInt64:
+			var v int64
+			err = binary.Read(d.r, binary.LittleEndian, &v)
+			node.Value = strconv.FormatInt(v, 10)
+		case kvTypeWString:
+			node.Value, err = d.wstring()
+		default:
+			return fmt.Errorf("unknown KeyValues type 0x%02x for key '%s'", kind, key)
+		}
+		if err != nil {
+			return err
+		}
+		parent.Children = append(parent.Children, node)
+	}
+}
+
+// key reads an entry key, either inline or as a string table index.
+func (d *kvDecoder) key() (string, error) {
+	if d.strings == nil {
+		return d.cstring()
+	}
+	var index uint32
+	if err := binary.Read(d.r, binary.LittleEndian, &index); err != nil {
+		return "", err
+	}
+	if int(index) >= len(d.strings) {
+		return "", fmt.Errorf("string table index %d out of range", index)
+	}
+	return d.strings[index], nil
+}
+
+// cstring reads a NUL-terminated string.
+func (d *kvDecoder) cstring() (string, error) {
+	s, err := d.r.ReadString(0)
+	if err != nil {
+		return "", err
+	}
+	return s[:len(s)-1], nil
+}
+
+// wstring reads a NUL-terminated UTF-16 string.
+func (d *kvDecoder) wstring() (string, error) {
+	var units []uint16
+	for {
+		var unit uint16
+		if err := binary.Read(d.r, binary.LittleEndian, &unit); err != nil {
+			return "", err
+		}
+		if unit == 0 {
+			return string(utf16.Decode(units)), nil
+		}
+		units = append(units, unit)
+	}
+}
+
+var (
+	localAppInfo     *AppInfo
+	localAppInfoErr  error
+	localAppInfoOnce sync.Once
+)
+
+// LocalAppInfo returns the index of the Steam client's appinfo.vdf, opened
+// once per process.
+func LocalAppInfo() (*AppInfo, error) {
+	localAppInfoOnce.Do(func() {
+		steamRoot, err := FindSteamRoot()
+		if err != nil {
+			localAppInfoErr = err
+			return
+		}
+		localAppInfo, localAppInfoErr = OpenAppInfo(filepath.Join(steamRoot, "appcache", "appinfo.vdf"))
+	})
+	return localAppInfo, localAppInfoErr
+}
+
+// localApp returns the local appinfo section of an app.
+func localApp(appID string) (*KeyValue, error) {
+	info, err := LocalAppInfo()
+	if err != nil {
+		return nil, err
+	}
+	return info.App(appID)
+}
+
+// AppDLCs lists the DLC AppIDs of an app, from the DLC list and the depots
+// that belong to DLCs.
+func AppDLCs(app *KeyValue) []string {
+	var dlcs []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		id = strings.TrimSpace(id)
+		if id != "" && id != "0" && !seen[id] {
+			seen[id] = true
+			dlcs = append(dlcs, id)
+		}
+	}
+	for _, id := range strings.Split(app.String("extended", "listofdlc"), ",") {
+		add(id)
+	}
+	if depots := app.Get("depots"); depots != nil {
+		for _, depot := range depots.Children {
+			add(depot.String("dlcappid"))
+		}
+	}
+	return dlcs
+}
+
+// LaunchEntry is a launch configuration from an app's appinfo.
+type LaunchEntry struct {
+	Executable, Arguments, WorkingDir, OSList string
+}
+
+// AppLaunchEntries returns the launch configurations of an app from the
+// local appinfo cache.
+func AppLaunchEntries(appID string) ([]LaunchEntry, error) {
+	app, err := localApp(appID)
+	if err != nil {
+		return nil, err
+	}
+	var entries []LaunchEntry
+	if launch := app.GetPath("config", "launch"); launch != nil {
+		for _, entry := range launch.Children {
+			entries = append(entries, LaunchEntry{
+				Executable: entry.String("executable"),
+				Arguments:  entry.String("arguments"),
+				WorkingDir: entry.String("workingdir"),
+				OSList:     entry.String("config", "oslist"),
+			})
+		}
+	}
+	return entries, nil
+}
+
+// achievementIconURL is the CDN location of achievement icons referenced by hash.
+const achievementIconURL = "https://cdn.cloudflare.steamstatic.com/steamcommunity/public/images/apps/%s/%s"
+
+// AppInfoProvider reads app metadata from the Steam client's local caches.
+// It implements MetadataProvider.
+type AppInfoProvider struct{}
+
+func (AppInfoProvider) ID() string { return "appinfo" }
+
+func (AppInfoProvider) AppName(appID string) (string, error) {
+	app, err := localApp(appID)
+	if err != nil {
+		return "", err
+	}
+	if name := app.String("common", "name"); name != "" {
+		return name, nil
+	}
+	return "", ErrNoMetadata
+}
+
+func (AppInfoProvider) DLCs(appID string) ([]string, error) {
+	app, err := localApp(appID)
+	if err != nil {
+		return nil, err
+	}
+	return AppDLCs(app), nil
+}
+
+// StatsSchema returns the decoded appcache/stats/UserGameStatsSchema_<appid>.bin
+// of an app, which the Steam client stores for games the user has played.
+func StatsSchema(appID string) (*KeyValue, error) {
+	steamRoot, err := FindSteamRoot()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(steamRoot, "appcache", "stats", fmt.Sprintf("UserGameStatsSchema_%s.bin", appID)))
+	if os.IsNotExist(err) {
+		return nil, ErrAppNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d := &kvDecoder{r: bufio.NewReader(f)}
+	root := &KeyValue{Children: []*KeyValue{}}
+	if err := d.decodeMap(root); err != nil {
+		return nil, fmt.Errorf("failed to decode stats schema of AppID %s: %w", appID, err)
+	}
+	if schema := root.Get(appID); schema != nil {
+		return schema, nil
+	}
+	return nil, ErrNoMetadata
+}
+
+// isAchievementStat reports whether a stats schema entry holds achievement bits.
+func isAchievementStat(stat *KeyValue) bool {
+	kind := stat.String("type")
+	return kind == "4" || kind == "5" || strings.EqualFold(kind, "ACHIEVEMENTS") || strings.EqualFold(kind, "GROUPACHIEVEMENTS")
+}
+
+func (AppInfoProvider) Achievements(appID string) ([]Achievement, error) {
+	schema, err := StatsSchema(appID)
+	if err != nil {
+		return nil, err
+	}
+	var achievements []Achievement
+	for _, stat := range schema.Get("stats").Children {
+		if !isAchievementStat(stat) {
+			continue
+		}
+		for _, bit := range stat.Get("bits").Children {
+			display := bit.Get("display")
+			hidden, _ := strconv.Atoi(display.String("hidden"))
+			achievement := Achievement{
+				Name:        bit.String("name"),
+				DisplayName: display.String("name", "english"),
+				Description: display.String("desc", "english"),
+				Hidden:      hidden,
+			}
+			if icon := display.String("icon"); icon != "" {
+				achievement.Icon = fmt.Sprintf(achievementIconURL, appID, icon)
+			}
+			if icon := display.String("icon_gray"); icon != "" {
+				achievement.IconGray = fmt.Sprintf(achievementIconURL, appID, icon)
+			}
+			achievements = append(achievements, achievement)
+		}
+	}
+	return achievements, nil
+}
+
+func (AppInfoProvider) Depots(appID string) ([]string, error) {
+	app, err := localApp(appID)
+	if err != nil {
+		return nil, err
+	}
+	var depots []string
+	if section := app.Get("depots"); section != nil {
+		for _, depot := range section.Children {
+			if _, err := strconv.ParseUint(depot.Key, 10, 32); err == nil && depot.IsObject() {
+				depots = append(depots, depot.Key)
+			}
+		}
+	}
+	return depots, nil
+}
+
+func (AppInfoProvider) Languages(appID string) ([]string, error) {
+	app, err := localApp(appID)
+	if err != nil {
+		return nil, err
+	}
+	var languages []string
+	if supported := app.GetPath("common", "supported_languages"); supported != nil {
+		for _, language := range supported.Children {
+			if language.String("supported") == "true" {
+				languages = append(languages, language.Key)
+			}
+		}
+	} else if section := app.GetPath("common", "languages"); section != nil {
+		for _, language := range section.Children {
+			if language.Value == "1" {
+				languages = append(languages, language.Key)
+			}
+		}
+	}
+	if len(languages) == 0 {
+		return nil, ErrNoMetadata
+	}
+	return languages, nil
+}
+
+func (AppInfoProvider) Leaderboards(appID string) ([]Leaderboard, error) {
+	return nil, ErrNoMetadata
+}
+
+// statTypes maps the stats schema's stat types to gbe_fork's.
+var statTypes = map[string]string{"1": "int", "2": "float", "3": "avgrate", "INT": "int", "FLOAT": "float", "AVGRATE": "avgrate"}
+
+func (AppInfoProvider) Stats(appID string) ([]Stat, error) {
+	schema, err := StatsSchema(appID)
+	if err != nil {
+		return nil, err
+	}
+	var stats []Stat
+	for _, stat := range schema.Get("stats").Children {
+		kind, ok := statTypes[strings.ToUpper(stat.String("type"))]
+		if !ok || stat.String("name") == "" {
+			continue
+		}
+		def := stat.String("default")
+		if def == "" {
+			def = "0"
+		}
+		stats = append(stats, Stat{Name: stat.String("name"), Type: kind, Default: def, Global: "0"})
+	}
+	return stats, nil
+}
+
+func (AppInfoProvider) Items(appID string) ([]InventoryItem, error) {
+	return nil, ErrNoMetadata
+}
@@ -0,0 +1,139 @@
+package steam
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// appInfoWriter builds appinfo.vdf test files.
+type appInfoWriter struct {
+	buf     bytes.Buffer
+	strings []string
+	v29     bool
+}
+
+func (w *appInfoWriter) key(b *bytes.Buffer, key string) {
+	if !w.v29 {
+		b.WriteString(key + "\x00")
+		return
+	}
+	for i, s := range w.strings {
+		if s == key {
+			binary.Write(b, binary.LittleEndian, uint32(i))
+			return
+		}
+	}
+	w.strings = append(w.strings, key)
+	binary.Write(b, binary.LittleEndian, uint32(len(w.strings)-1))
+}
+
+func (w *appInfoWriter) str(b *bytes.Buffer, key, value string) {
+	b.WriteByte(kvTypeString)
+	w.key(b, key)
+	b.WriteString(value + "\x00")
+}
+
+func (w *appInfoWriter) app(appID uint32, name, dlcs string, dlcDepot uint32) {
+	var kv bytes.Buffer
+	kv.WriteByte(kvTypeMap)
+	w.key(&kv, "appinfo")
+	kv.WriteByte(kvTypeInt32)
+	w.key(&kv, "appid")
+	binary.Write(&kv, binary.LittleEndian, int32(appID))
+	kv.WriteByte(kvTypeMap)
+	w.key(&kv, "common")
+	w.str(&kv, "name", name)
+	kv.WriteByte(kvTypeEnd)
+	kv.WriteByte(kvTypeMap)
+	w.key(&kv, "extended")
+	w.str(&kv, "listofdlc", dlcs)
+	kv.WriteByte(kvTypeEnd)
+	kv.WriteByte(kvTypeMap)
+	w.key(&kv, "depots")
+	kv.WriteByte(kvTypeMap)
+	w.key(&kv, "1001")
+	kv.WriteByte(kvTypeInt32)
+	w.key(&kv, "dlcappid")
+	binary.Write(&kv, binary.LittleEndian, int32(dlcDepot))
+	kv.WriteByte(kvTypeEnd)
+	kv.WriteByte(kvTypeEnd)
+	kv.WriteByte(kvTypeEnd)
+	kv.WriteByte(kvTypeEnd)
+
+	binary.Write(&w.buf, binary.LittleEndian, appID)
+	binary.Write(&w.buf, binary.LittleEndian, uint32(4+4+8+20+4+20+kv.Len()))
+	w.buf.Write(make([]byte, 4+4+8+20+4+20))
+	w.buf.Write(kv.Bytes())
+}
+
+func (w *appInfoWriter) bytes() []byte {
+	var out bytes.Buffer
+	magic := uint32(appInfoMagic28)
+	if w.v29 {
+		magic = appInfoMagic29
+	}
+	binary.Write(&out, binary.LittleEndian, magic)
+	binary.Write(&out, binary.LittleEndian, uint32(1))
+	body := append(w.buf.Bytes(), 0, 0, 0, 0)
+	if w.v29 {
+		binary.Write(&out, binary.LittleEndian, int64(out.Len()+8+len(body)))
+	}
+	out.Write(body)
+	if w.v29 {
+		binary.Write(&out, binary.LittleEndian, uint32(len(w.strings)))
+		for _, s := range w.strings {
+			out.WriteString(s + "\x00")
+		}
+	}
+	return out.Bytes()
+}
+
+func TestAppInfo(t *testing.T) {
+	for _, v29 := range []bool{false, true} {
+		w := &appInfoWriter{v29: v29}
+		w.app(480, "Spacewar", "110902, 110903", 110904)
+		w.app(570, "Dota 2", "", 0)
+
+		path := filepath.Join(t.TempDir(), "appinfo.vdf")
+		if err := os.WriteFile(path, w.bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := OpenAppInfo(path)
+		if err != nil {
+			t.Fatalf("OpenAppInfo failed (v29=%v): %v", v29, err)
+		}
+		defer info.Close()
+
+		app, err := info.App("480")
+		if err != nil {
+			t.Fatalf("App failed (v29=%v): %v", v29, err)
+		}
+		if got := app.String("common", "name"); got != "Spacewar" {
+			t.Errorf("Expected name %q (v29=%v), got %q", "Spacewar", v29, got)
+		}
+		if got := app.String("appid"); got != "480" {
+			t.Errorf("Expected appid %q (v29=%v), got %q", "480", v29, got)
+		}
+		expected := []string{"110902", "110903", "110904"}
+		if got := AppDLCs(app); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected DLCs %q (v29=%v), got %q", expected, v29, got)
+		}
+
+		other, err := info.App("570")
+		if err != nil {
+			t.Fatalf("App failed for second entry (v29=%v): %v", v29, err)
+		}
+		if got := other.String("common", "name"); got != "Dota 2" {
+			t.Errorf("Expected name %q (v29=%v), got %q", "Dota 2", v29, got)
+		}
+
+		if _, err := info.App("10"); err != ErrAppNotFound {
+			t.Errorf("Expected ErrAppNotFound (v29=%v), got %v", v29, err)
+		}
+	}
+}
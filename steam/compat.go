@@ -0,0 +1,86 @@
+package steam
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// systemCompatToolDirs are system-wide compatibility tool directories.
+var systemCompatToolDirs = []string{
+	"/usr/share/steam/compatibilitytools.d",
+	"/usr/local/share/steam/compatibilitytools.d",
+}
+
+// ProtonInstall is a Proton build found in a Steam installation.
+type ProtonInstall struct {
+	// Name is the install's directory name, e.g. "Proton 9.0" or "GE-Proton9-20".
+	Name string
+	// Path is the directory containing the proton script.
+	Path string
+}
+
+// Script returns the path of the install's proton launcher script.
+func (p ProtonInstall) Script() string {
+	return filepath.Join(p.Path, "proton")
+}
+
+// FindProtonInstalls lists the Proton builds in the Steam client's
+// compatibilitytools.d, the system compatibility tool directories and the
+// steamapps/common folder of every Steam library.
+func FindProtonInstalls() ([]ProtonInstall, error) {
+	var searchDirs []string
+	steamRoot, err := FindSteamRoot()
+	if err == nil {
+		searchDirs = append(searchDirs, filepath.Join(steamRoot, "compatibilitytools.d"))
+		libraries, err := LibraryFolders(steamRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, library := range libraries {
+			searchDirs = append(searchDirs, filepath.Join(library, "steamapps", "common"))
+		}
+	}
+	searchDirs = append(searchDirs, systemCompatToolDirs...)
+
+	var installs []ProtonInstall
+	seen := make(map[string]bool)
+	for _, dir := range searchDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if seen[path] {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(path, "proton")); err != nil {
+				continue
+			}
+			seen[path] = true
+			installs = append(installs, ProtonInstall{Name: entry.Name(), Path: path})
+		}
+	}
+	sort.Slice(installs, func(i, j int) bool { return installs[i].Name < installs[j].Name })
+	return installs, nil
+}
+
+// FindProton returns the Proton install with the given name, compared
+// case-insensitively. A path to a Proton directory is also accepted.
+func FindProton(name string) (*ProtonInstall, bool) {
+	if _, err := os.Stat(filepath.Join(name, "proton")); err == nil {
+		return &ProtonInstall{Name: filepath.Base(name), Path: name}, true
+	}
+	installs, err := FindProtonInstalls()
+	if err != nil {
+		return nil, false
+	}
+	for _, install := range installs {
+		if strings.EqualFold(install.Name, name) {
+			return &install, true
+		}
+	}
+	return nil, false
+}
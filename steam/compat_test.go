@@ -0,0 +1,51 @@
+package steam
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindProtonInstalls(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	steamRoot := filepath.Join(home, ".steam", "steam")
+	writeFile(t, filepath.Join(steamRoot, "compatibilitytools.d", "GE-Proton9-20", "proton"), "#!/bin/sh\n")
+	writeFile(t, filepath.Join(steamRoot, "steamapps", "common", "Proton 9.0", "proton"), "#!/bin/sh\n")
+	writeFile(t, filepath.Join(steamRoot, "steamapps", "common", "Spacewar", "Spacewar.exe"), "")
+
+	// Test the installs of the Steam client, ignoring system-wide ones
+	installs, err := FindProtonInstalls()
+	if err != nil {
+		t.Fatalf("FindProtonInstalls failed: %v", err)
+	}
+	var names []string
+	for _, install := range installs {
+		if strings.HasPrefix(install.Path, home) {
+			names = append(names, install.Name)
+		}
+	}
+	if strings.Join(names, ",") != "GE-Proton9-20,Proton 9.0" {
+		t.Errorf("Expected GE-Proton9-20 and Proton 9.0, got %v", names)
+	}
+
+	// Test finding an install by name, case-insensitively
+	install, ok := FindProton("proton 9.0")
+	if !ok {
+		t.Fatalf("FindProton was expected to find 'proton 9.0'")
+	}
+	if expected := filepath.Join(steamRoot, "steamapps", "common", "Proton 9.0", "proton"); install.Script() != expected {
+		t.Errorf("Expected script %q, got %q", expected, install.Script())
+	}
+
+	// Test finding an install by path
+	path := filepath.Join(steamRoot, "compatibilitytools.d", "GE-Proton9-20")
+	if install, ok := FindProton(path); !ok || install.Name != "GE-Proton9-20" {
+		t.Errorf("Expected GE-Proton9-20 from its path, got %v", install)
+	}
+
+	// Test an unknown install
+	if _, ok := FindProton("Proton 1.0"); ok {
+		t.Errorf("Expected Proton 1.0 not to be found")
+	}
+}
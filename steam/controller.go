@@ -0,0 +1,241 @@
+package steam
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// controllerConfigsAppID is the AppID Steam stores workshop controller configurations under.
+const controllerConfigsAppID = "241100"
+
+// ControllerActionSet is an action set of a game's Steam Input configuration,
+// with the controller inputs bound to each action.
+type ControllerActionSet struct {
+	Name    string
+	Actions []ControllerAction
+}
+
+// ControllerAction is an action with the gbe_fork names of its inputs: the
+// buttons of a digital action, or an analog input and its mode such as
+// LJOY=joystick_move.
+type ControllerAction struct {
+	Name   string
+	Inputs []string
+}
+
+// Text formats the action set in gbe_fork's controller text format, one
+// action=inputs line per action.
+func (s *ControllerActionSet) Text() string {
+	var text strings.Builder
+	for _, action := range s.Actions {
+		text.WriteString(fmt.Sprintf("%s=%s\n", action.Name, strings.Join(action.Inputs, ",")))
+	}
+	return text.String()
+}
+
+// controllerButtons maps the inputs of each Steam Input source to gbe_fork button names.
+var controllerButtons = map[string]map[string]string{
+	"button_diamond": {"button_a": "A", "button_b": "B", "button_x": "X", "button_y": "Y"},
+	"dpad":           {"dpad_north": "DUP", "dpad_south": "DDOWN", "dpad_west": "DLEFT", "dpad_east": "DRIGHT"},
+	"joystick": {"click": "LSTICK", "dpad_north": "DLJOYUP", "dpad_south": "DLJOYDOWN",
+		"dpad_west": "DLJOYLEFT", "dpad_east": "DLJOYRIGHT"},
+	"right_joystick": {"click": "RSTICK", "dpad_north": "DRJOYUP", "dpad_south": "DRJOYDOWN",
+		"dpad_west": "DRJOYLEFT", "dpad_east": "DRJOYRIGHT"},
+	"left_trigger":  {"click": "DLTRIGGER"},
+	"right_trigger": {"click": "DRTRIGGER"},
+	"switch": {"button_escape": "START", "button_menu": "BACK",
+		"left_bumper": "LBUMPER", "right_bumper": "RBUMPER"},
+}
+
+// controllerAnalogs maps the Steam Input sources to gbe_fork analog input names.
+var controllerAnalogs = map[string]string{
+	"joystick":       "LJOY",
+	"right_joystick": "RJOY",
+	"dpad":           "DPAD",
+	"left_trigger":   "LTRIGGER",
+	"right_trigger":  "RTRIGGER",
+}
+
+// controllerSets collects the action sets of a configuration in order.
+type controllerSets struct {
+	sets []*ControllerActionSet
+}
+
+// action returns an action of a set, adding both as needed.
+func (c *controllerSets) action(set, name string) *ControllerAction {
+	i := slices.IndexFunc(c.sets, func(s *ControllerActionSet) bool { return s.Name == set })
+	if i < 0 {
+		c.sets = append(c.sets, &ControllerActionSet{Name: set})
+		i = len(c.sets) - 1
+	}
+	s := c.sets[i]
+	j := slices.IndexFunc(s.Actions, func(a ControllerAction) bool { return a.Name == name })
+	if j < 0 {
+		s.Actions = append(s.Actions, ControllerAction{Name: name})
+		j = len(s.Actions) - 1
+	}
+	return &s.Actions[j]
+}
+
+// ParseControllerConfig converts a Steam Input configuration (a
+// controller_mappings VDF document) into gbe_fork action sets. Only the
+// groups each preset binds as active are used, and only their game_action
+// bindings, so templates that emulate a keyboard or gamepad yield nothing.
+func ParseControllerConfig(root *KeyValue) ([]ControllerActionSet, error) {
+	mappings := root.Get("controller_mappings")
+	if mappings == nil {
+		return nil, fmt.Errorf("not a controller configuration: missing controller_mappings")
+	}
+
+	groups := make(map[string]*KeyValue)
+	for _, child := range mappings.Children {
+		if strings.EqualFold(child.Key, "group") {
+			groups[child.String("id")] = child
+		}
+	}
+
+	sets := &controllerSets{}
+	for _, preset := range mappings.Children {
+		if !strings.EqualFold(preset.Key, "preset") {
+			continue
+		}
+		for _, binding := range children(preset.Get("group_source_bindings")) {
+			fields := strings.Fields(binding.Value)
+			group := groups[binding.Key]
+			if len(fields) != 2 || fields[1] != "active" || group == nil {
+				continue
+			}
+			source := fields[0]
+
+			if analog, ok := controllerAnalogs[source]; ok {
+				mode := group.String("mode")
+				if strings.HasSuffix(source, "_trigger") {
+					mode = "trigger"
+				}
+				for _, action := range children(group.Get("gameactions")) {
+					a := sets.action(action.Key, action.Value)
+					a.Inputs = []string{analog + "=" + mode}
+				}
+			}
+
+			buttons := controllerButtons[source]
+			for _, input := range children(group.Get("inputs")) {
+				button, ok := buttons[input.Key]
+				if !ok {
+					continue
+				}
+				for _, activator := range children(input.Get("activators")) {
+					for _, b := range children(activator.Get("bindings")) {
+						set, name, ok := gameAction(b.Value)
+						if !ok {
+							continue
+						}
+						a := sets.action(set, name)
+						if len(a.Inputs) == 1 && strings.Contains(a.Inputs[0], "=") {
+							// Analog actions take no buttons
+							continue
+						}
+						if !slices.Contains(a.Inputs, button) {
+							a.Inputs = append(a.Inputs, button)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	result := make([]ControllerActionSet, len(sets.sets))
+	for i, s := range sets.sets {
+		result[i] = *s
+	}
+	return result, nil
+}
+
+// children returns the children of a node, which may be missing.
+func children(kv *KeyValue) []*KeyValue {
+	if kv == nil {
+		return nil
+	}
+	return kv.Children
+}
+
+// gameAction parses a "game_action <set> <action>, <title>" binding.
+func gameAction(binding string) (set, action string, ok bool) {
+	binding, _, _ = strings.Cut(binding, ",")
+	fields := strings.Fields(binding)
+	if len(fields) != 3 || fields[0] != "game_action" {
+		return "", "", false
+	}
+	return fields[1], fields[2], true
+}
+
+// FindControllerConfigs returns the Steam Input configurations of an app
+// found in the Steam install: the user's own configurations, then those
+// downloaded from the workshop. Xbox controller configurations come first
+// within each, as the emulator presents an Xbox controller.
+func FindControllerConfigs(appID string) ([]string, error) {
+	steamRoot, err := FindSteamRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []string
+	add := func(paths []string) {
+		slices.SortStableFunc(paths, func(a, b string) int {
+			return boolRank(isXboxConfig(b)) - boolRank(isXboxConfig(a))
+		})
+		configs = append(configs, paths...)
+	}
+
+	own, _ := filepath.Glob(filepath.Join(steamRoot, "steamapps", "common", "Steam Controller Configs", "*", "config", appID, "*.vdf"))
+	remote, _ := filepath.Glob(filepath.Join(steamRoot, "userdata", "*", controllerConfigsAppID, "remote", "controller_config", appID, "*.vdf"))
+	add(append(own, remote...))
+
+	libraries, err := LibraryFolders(steamRoot)
+	if err != nil {
+		return configs, nil
+	}
+	var workshop []string
+	for _, library := range libraries {
+		files, _ := filepath.Glob(filepath.Join(library, "steamapps", "workshop", "content", controllerConfigsAppID, "*", "*"))
+		for _, file := range files {
+			root, err := ParseVDFFile(file)
+			if err == nil && root.String("controller_mappings", "game") == appID {
+				workshop = append(workshop, file)
+			}
+		}
+	}
+	add(workshop)
+	return configs, nil
+}
+
+// isXboxConfig reports whether a configuration file is meant for Xbox controllers.
+func isXboxConfig(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	return strings.Contains(name, "xbox") || strings.Contains(name, "xinput")
+}
+
+// boolRank orders true before false.
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ControllerGlyphsDir returns the Steam client's directory of controller
+// button images, which the emulator shows for Steam Input glyph requests.
+func ControllerGlyphsDir() (string, error) {
+	steamRoot, err := FindSteamRoot()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(steamRoot, "tenfoot", "resource", "images", "library", "controller", "api")
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("controller glyphs not found: %w", err)
+	}
+	return dir, nil
+}
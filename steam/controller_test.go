@@ -0,0 +1,148 @@
+package steam
+
+import (
+	"strings"
+	"testing"
+)
+
+const controllerVDF = `"controller_mappings"
+{
+	"version"		"3"
+	"game"		"480"
+	"group"
+	{
+		"id"		"0"
+		"mode"		"four_buttons"
+		"inputs"
+		{
+			"button_a"
+			{
+				"activators"
+				{
+					"Full_Press"
+					{
+						"bindings"
+						{
+							"binding"		"game_action InGameControls fire, #Action_Fire"
+						}
+					}
+				}
+			}
+			"button_b"
+			{
+				"activators"
+				{
+					"Full_Press"
+					{
+						"bindings"
+						{
+							"binding"		"xinput_button B"
+						}
+					}
+				}
+			}
+		}
+	}
+	"group"
+	{
+		"id"		"1"
+		"mode"		"joystick_move"
+		"inputs"
+		{
+			"click"
+			{
+				"activators"
+				{
+					"Full_Press"
+					{
+						"bindings"
+						{
+							"binding"		"game_action InGameControls fire, #Action_Fire"
+						}
+					}
+				}
+			}
+		}
+		"gameactions"
+		{
+			"InGameControls"		"Move"
+		}
+	}
+	"group"
+	{
+		"id"		"2"
+		"mode"		"trigger"
+		"gameactions"
+		{
+			"InGameControls"		"Throttle"
+		}
+	}
+	"group"
+	{
+		"id"		"3"
+		"mode"		"switches"
+		"inputs"
+		{
+			"button_escape"
+			{
+				"activators"
+				{
+					"Full_Press"
+					{
+						"bindings"
+						{
+							"binding"		"game_action MenuControls menu_cancel, #Menu_Cancel"
+						}
+					}
+				}
+			}
+		}
+	}
+	"preset"
+	{
+		"id"		"0"
+		"name"		"InGameControls"
+		"group_source_bindings"
+		{
+			"0"		"button_diamond active"
+			"1"		"joystick active"
+			"2"		"left_trigger active"
+			"3"		"switch active modeshift"
+		}
+	}
+	"preset"
+	{
+		"id"		"1"
+		"name"		"MenuControls"
+		"group_source_bindings"
+		{
+			"3"		"switch active"
+		}
+	}
+}`
+
+func TestParseControllerConfig(t *testing.T) {
+	root, err := ParseVDF(strings.NewReader(controllerVDF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets, err := ParseControllerConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 2 || sets[0].Name != "InGameControls" || sets[1].Name != "MenuControls" {
+		t.Fatalf("sets = %+v", sets)
+	}
+
+	expected := "fire=A,LSTICK\nMove=LJOY=joystick_move\nThrottle=LTRIGGER=trigger\n"
+	if got := sets[0].Text(); got != expected {
+		t.Errorf("InGameControls = %q, want %q", got, expected)
+	}
+	if got := sets[1].Text(); got != "menu_cancel=START\n" {
+		t.Errorf("MenuControls = %q", got)
+	}
+
+	if _, err := ParseControllerConfig(&KeyValue{Key: "", Children: []*KeyValue{}}); err == nil {
+		t.Error("document without controller_mappings accepted")
+	}
+}
@@ -0,0 +1,81 @@
+package steam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gbe_fork_helper/config"
+)
+
+// DLCOverridesFileName is the DLC overrides file, both per game and in GbeDir.
+const DLCOverridesFileName = "dlc_overrides.json"
+
+// DLCOverrides lists manual corrections to the fetched DLCs of a game.
+type DLCOverrides struct {
+	// Add maps extra DLC AppIDs to their names. Names also replace fetched ones.
+	Add map[string]string `json:"add,omitempty"`
+	// Exclude lists DLC AppIDs that are never written.
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// merge adds the entries of other, which take precedence.
+func (o *DLCOverrides) merge(other DLCOverrides) {
+	if o.Add == nil {
+		o.Add = make(map[string]string)
+	}
+	for id, name := range other.Add {
+		o.Add[id] = name
+	}
+	o.Exclude = append(o.Exclude, other.Exclude...)
+}
+
+// excluded reports whether a DLC AppID is excluded.
+func (o *DLCOverrides) excluded(id string) bool {
+	for _, excluded := range o.Exclude {
+		if excluded == id {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadDLCOverrides loads the DLC overrides of appID from the global file in
+// GbeDir, keyed by AppID, and the game's own file in gameDir, which takes
+// precedence. Missing files are ignored.
+func LoadDLCOverrides(appID, gameDir string) (DLCOverrides, error) {
+	var overrides DLCOverrides
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return overrides, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	var global map[string]DLCOverrides
+	if err := readJSONFile(filepath.Join(homeDir, config.GbeDir, DLCOverridesFileName), &global); err != nil {
+		return overrides, err
+	}
+	overrides.merge(global[appID])
+
+	var local DLCOverrides
+	if err := readJSONFile(filepath.Join(gameDir, DLCOverridesFileName), &local); err != nil {
+		return overrides, err
+	}
+	overrides.merge(local)
+	return overrides, nil
+}
+
+// readJSONFile decodes the JSON file at path into v, leaving v untouched if the file is missing.
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode '%s': %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package steam
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gbe_fork_helper/config"
+)
+
+func TestLoadDLCOverrides(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	gbeDir := filepath.Join(home, config.GbeDir)
+	if err := os.MkdirAll(gbeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	global := `{"480": {"add": {"1": "Global", "2": "Shared"}, "exclude": ["3"]}, "10": {"add": {"9": "Other"}}}`
+	if err := os.WriteFile(filepath.Join(gbeDir, DLCOverridesFileName), []byte(global), 0644); err != nil {
+		t.Fatal(err)
+	}
+	game := t.TempDir()
+
+	// Test the global overrides on their own
+	overrides, err := LoadDLCOverrides("480", game)
+	if err != nil {
+		t.Fatalf("LoadDLCOverrides failed: %v", err)
+	}
+	if len(overrides.Add) != 2 || overrides.Add["2"] != "Shared" {
+		t.Errorf("Expected the global additions of AppID 480, got %v", overrides.Add)
+	}
+
+	// Test that the game's file wins over the global one
+	if err := os.WriteFile(filepath.Join(game, DLCOverridesFileName), []byte(`{"add": {"2": "Local"}, "exclude": ["4"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	overrides, err = LoadDLCOverrides("480", game)
+	if err != nil {
+		t.Fatalf("LoadDLCOverrides failed: %v", err)
+	}
+	if len(overrides.Add) != 2 {
+		t.Errorf("Expected 2 additions, got %v", overrides.Add)
+	}
+	if overrides.Add["1"] != "Global" {
+		t.Errorf("Expected global name for DLC 1, got %q", overrides.Add["1"])
+	}
+	if overrides.Add["2"] != "Local" {
+		t.Errorf("Expected game name for DLC 2, got %q", overrides.Add["2"])
+	}
+
+	// Test that the excludes of both files are merged
+	for _, id := range []string{"3", "4"} {
+		if !overrides.excluded(id) {
+			t.Errorf("Expected DLC %s to be excluded", id)
+		}
+	}
+	if overrides.excluded("1") {
+		t.Errorf("Expected DLC 1 not to be excluded")
+	}
+}
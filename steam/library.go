@@ -0,0 +1,147 @@
+package steam
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// steamRootCandidates lists the usual Steam install locations, relative to the
+// home directory unless absolute.
+var steamRootCandidates = []string{
+	".steam/steam",
+	".local/share/Steam",
+	".var/app/com.valvesoftware.Steam/.local/share/Steam",
+	"Library/Application Support/Steam",
+	`C:\Program Files (x86)\Steam`,
+	`C:\Program Files\Steam`,
+}
+
+// AppManifest describes an installed app from its appmanifest_<appid>.acf.
+type AppManifest struct {
+	AppID, Name, InstallDir string
+	// Library is the Steam library folder containing the app.
+	Library string
+}
+
+// InstallPath returns the directory the app is installed in.
+func (m *AppManifest) InstallPath() string {
+	return filepath.Join(m.Library, "steamapps", "common", m.InstallDir)
+}
+
+// FindSteamRoot returns the Steam client's install directory.
+func FindSteamRoot() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	for _, candidate := range steamRootCandidates {
+		if !filepath.IsAbs(candidate) {
+			if runtime.GOOS == "windows" && strings.HasPrefix(candidate, ".") {
+				continue
+			}
+			candidate = filepath.Join(homeDir, candidate)
+		}
+		if _, err := os.Stat(filepath.Join(candidate, "steamapps")); err == nil {
+			if resolved, err := filepath.EvalSymlinks(candidate); err == nil {
+				return resolved, nil
+			}
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("Steam installation not found")
+}
+
+// LibraryFolders returns the Steam library folders listed in the Steam
+// client's libraryfolders.vdf. The Steam root itself is always included.
+func LibraryFolders(steamRoot string) ([]string, error) {
+	libraries := []string{steamRoot}
+	root, err := ParseVDFFile(filepath.Join(steamRoot, "steamapps", "libraryfolders.vdf"))
+	if os.IsNotExist(err) {
+		return libraries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{filepath.Clean(steamRoot): true}
+	for _, folder := range root.Get("libraryfolders").Children {
+		// Older files store the path directly as the value
+		path := folder.Value
+		if folder.IsObject() {
+			path = folder.String("path")
+		}
+		if path == "" || seen[filepath.Clean(path)] {
+			continue
+		}
+		seen[filepath.Clean(path)] = true
+		libraries = append(libraries, path)
+	}
+	return libraries, nil
+}
+
+// ReadAppManifest parses an appmanifest_<appid>.acf file.
+func ReadAppManifest(path string) (*AppManifest, error) {
+	root, err := ParseVDFFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := root.Get("AppState")
+	if state == nil {
+		return nil, fmt.Errorf("'%s' has no AppState section", path)
+	}
+	return &AppManifest{
+		AppID:      state.String("appid"),
+		Name:       state.String("name"),
+		InstallDir: state.String("installdir"),
+		Library:    filepath.Dir(filepath.Dir(path)),
+	}, nil
+}
+
+// InstalledApps returns the manifests of every app installed in the local Steam libraries.
+func InstalledApps() ([]*AppManifest, error) {
+	steamRoot, err := FindSteamRoot()
+	if err != nil {
+		return nil, err
+	}
+	libraries, err := LibraryFolders(steamRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var apps []*AppManifest
+	for _, library := range libraries {
+		matches, _ := filepath.Glob(filepath.Join(library, "steamapps", "appmanifest_*.acf"))
+		for _, path := range matches {
+			app, err := ReadAppManifest(path)
+			if err != nil {
+				log.Printf("WARN: %v", err)
+				continue
+			}
+			apps = append(apps, app)
+		}
+	}
+	return apps, nil
+}
+
+// FindApp locates an installed app by AppID in the local Steam libraries.
+func FindApp(appID string) (*AppManifest, error) {
+	steamRoot, err := FindSteamRoot()
+	if err != nil {
+		return nil, err
+	}
+	libraries, err := LibraryFolders(steamRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, library := range libraries {
+		path := filepath.Join(library, "steamapps", fmt.Sprintf("appmanifest_%s.acf", appID))
+		if _, err := os.Stat(path); err == nil {
+			return ReadAppManifest(path)
+		}
+	}
+	return nil, fmt.Errorf("AppID %s is not installed in any Steam library", appID)
+}
@@ -0,0 +1,88 @@
+package steam
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFile writes content to path, creating its directory.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLibraryFolders(t *testing.T) {
+	steamRoot := t.TempDir()
+	games := t.TempDir()
+
+	// Test without libraryfolders.vdf
+	libraries, err := LibraryFolders(steamRoot)
+	if err != nil {
+		t.Fatalf("LibraryFolders failed: %v", err)
+	}
+	if !reflect.DeepEqual(libraries, []string{steamRoot}) {
+		t.Errorf("Expected only the Steam root, got %v", libraries)
+	}
+
+	// Test the current format, listing the Steam root again, and the older one
+	writeFile(t, filepath.Join(steamRoot, "steamapps", "libraryfolders.vdf"), `"libraryfolders"
+{
+	"0"
+	{
+		"path"		"`+steamRoot+`"
+		"apps"
+		{
+			"480"		"1024"
+		}
+	}
+	"1"
+	{
+		"path"		"`+games+`"
+	}
+	"2"		"/mnt/old"
+}
+`)
+	libraries, err = LibraryFolders(steamRoot)
+	if err != nil {
+		t.Fatalf("LibraryFolders failed: %v", err)
+	}
+	expected := []string{steamRoot, games, "/mnt/old"}
+	if !reflect.DeepEqual(libraries, expected) {
+		t.Errorf("Expected %v, got %v", expected, libraries)
+	}
+}
+
+func TestFindApp(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	steamRoot := filepath.Join(home, ".steam", "steam")
+	games := t.TempDir()
+	writeFile(t, filepath.Join(steamRoot, "steamapps", "libraryfolders.vdf"),
+		"\"libraryfolders\"\n{\n\t\"1\"\n\t{\n\t\t\"path\"\t\t\""+games+"\"\n\t}\n}\n")
+	writeFile(t, filepath.Join(games, "steamapps", "appmanifest_480.acf"),
+		"\"AppState\"\n{\n\t\"appid\"\t\t\"480\"\n\t\"name\"\t\t\"Spacewar\"\n\t\"installdir\"\t\t\"Spacewar\"\n}\n")
+
+	// Test an app installed in a second library
+	app, err := FindApp("480")
+	if err != nil {
+		t.Fatalf("FindApp failed: %v", err)
+	}
+	if app.Name != "Spacewar" {
+		t.Errorf("Expected name %q, got %q", "Spacewar", app.Name)
+	}
+	if expected := filepath.Join(games, "steamapps", "common", "Spacewar"); app.InstallPath() != expected {
+		t.Errorf("Expected install path %q, got %q", expected, app.InstallPath())
+	}
+
+	// Test an app that is not installed
+	if _, err := FindApp("570"); err == nil {
+		t.Errorf("FindApp was expected to fail for an app that is not installed but succeeded")
+	}
+}
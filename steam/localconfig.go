@@ -0,0 +1,124 @@
+package steam
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// steamID64Base is the SteamID64 of account ID 0 in the public universe.
+const steamID64Base = 76561197960265728
+
+// UserIDs returns the account IDs with a userdata directory in the Steam
+// installation, the most recently logged in user first when known.
+func UserIDs(steamRoot string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(steamRoot, "userdata"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read userdata directory: %w", err)
+	}
+
+	recent := ""
+	if users, err := ParseVDFFile(filepath.Join(steamRoot, "config", "loginusers.vdf")); err == nil {
+		for _, user := range users.Get("users").Children {
+			if user.String("MostRecent") == "1" {
+				if id, err := strconv.ParseUint(user.Key, 10, 64); err == nil && id > steamID64Base {
+					recent = strconv.FormatUint(id-steamID64Base, 10)
+				}
+			}
+		}
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == "0" {
+			continue
+		}
+		if _, err := strconv.ParseUint(entry.Name(), 10, 32); err != nil {
+			continue
+		}
+		if entry.Name() == recent {
+			ids = append([]string{entry.Name()}, ids...)
+		} else {
+			ids = append(ids, entry.Name())
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no Steam users found in '%s'", steamRoot)
+	}
+	return ids, nil
+}
+
+// LocalConfigPath returns the path of a user's localconfig.vdf.
+func LocalConfigPath(steamRoot, userID string) string {
+	return filepath.Join(steamRoot, "userdata", userID, "config", "localconfig.vdf")
+}
+
+// appConfig returns the per-app section of a parsed localconfig.vdf,
+// creating the path to it when create is set.
+func appConfig(root *KeyValue, appID string, create bool) (*KeyValue, error) {
+	store := root.Get("UserLocalConfigStore")
+	if store == nil {
+		return nil, fmt.Errorf("not a localconfig.vdf: missing UserLocalConfigStore")
+	}
+	if !create {
+		return store.GetPath("Software", "Valve", "Steam", "apps", appID), nil
+	}
+	return store.Object("Software").Object("Valve").Object("Steam").Object("apps").Object(appID), nil
+}
+
+// GetLaunchOptions returns the launch options set for an app in a parsed localconfig.vdf.
+func GetLaunchOptions(root *KeyValue, appID string) (string, error) {
+	app, err := appConfig(root, appID, false)
+	if err != nil {
+		return "", err
+	}
+	return app.String("LaunchOptions"), nil
+}
+
+// SetLaunchOptions sets the launch options for an app in a parsed
+// localconfig.vdf. An empty value removes them.
+func SetLaunchOptions(root *KeyValue, appID, value string) error {
+	app, err := appConfig(root, appID, value != "")
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		if app != nil {
+			app.Remove("LaunchOptions")
+		}
+		return nil
+	}
+	app.Set("LaunchOptions", value)
+	return nil
+}
+
+// IsRunning reports whether the Steam client is running.
+func IsRunning() bool {
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("tasklist", "/FI", "IMAGENAME eq steam.exe", "/NH").Output()
+		return err == nil && strings.Contains(strings.ToLower(string(out)), "steam.exe")
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		// No procfs, e.g. macOS
+		return exec.Command("pgrep", "-x", "steam_osx").Run() == nil || exec.Command("pgrep", "-x", "steam").Run() == nil
+	}
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		if name := strings.TrimSpace(string(comm)); name == "steam" || name == "steamwebhelper" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package steam
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUserIDs(t *testing.T) {
+	steamRoot := t.TempDir()
+
+	// Test without a userdata directory
+	if _, err := UserIDs(steamRoot); err == nil {
+		t.Fatalf("UserIDs was expected to fail without userdata but succeeded")
+	}
+
+	// Test that the anonymous user 0 and other entries are skipped
+	for _, name := range []string{"0", "1000", "2000", "config"} {
+		if err := os.MkdirAll(filepath.Join(steamRoot, "userdata", name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(steamRoot, "userdata", "3000"), "not a user")
+	ids, err := UserIDs(steamRoot)
+	if err != nil {
+		t.Fatalf("UserIDs failed: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []string{"1000", "2000"}) {
+		t.Errorf("Expected [1000 2000], got %v", ids)
+	}
+
+	// Test that the most recent user from loginusers.vdf comes first
+	writeFile(t, filepath.Join(steamRoot, "config", "loginusers.vdf"), `"users"
+{
+	"76561197960266728"
+	{
+		"AccountName"		"first"
+		"MostRecent"		"0"
+	}
+	"76561197960267728"
+	{
+		"AccountName"		"second"
+		"MostRecent"		"1"
+	}
+}
+`)
+	ids, err = UserIDs(steamRoot)
+	if err != nil {
+		t.Fatalf("UserIDs failed: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []string{"2000", "1000"}) {
+		t.Errorf("Expected [2000 1000], got %v", ids)
+	}
+}
+
+func TestLaunchOptions(t *testing.T) {
+	input := `"UserLocalConfigStore"
+{
+	"Software"
+	{
+		"Valve"
+		{
+			"Steam"
+			{
+				"apps"
+				{
+					"480"
+					{
+						"LaunchOptions"		"PROTON_LOG=1 %command%"
+					}
+				}
+			}
+		}
+	}
+}
+`
+	root, err := ParseVDF(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseVDF failed: %v", err)
+	}
+
+	// Test reading existing and missing launch options
+	if got, err := GetLaunchOptions(root, "480"); err != nil || got != "PROTON_LOG=1 %command%" {
+		t.Errorf("Expected existing launch options, got %q (%v)", got, err)
+	}
+	if got, err := GetLaunchOptions(root, "570"); err != nil || got != "" {
+		t.Errorf("Expected no launch options, got %q (%v)", got, err)
+	}
+
+	// Test setting launch options for an app without a section
+	if err := SetLaunchOptions(root, "570", "%command% -novid"); err != nil {
+		t.Fatalf("SetLaunchOptions failed: %v", err)
+	}
+	if got, _ := GetLaunchOptions(root, "570"); got != "%command% -novid" {
+		t.Errorf("Expected %q, got %q", "%command% -novid", got)
+	}
+
+	// Test that an empty value removes the launch options
+	if err := SetLaunchOptions(root, "480", ""); err != nil {
+		t.Fatalf("SetLaunchOptions failed: %v", err)
+	}
+	app := root.GetPath("UserLocalConfigStore", "Software", "Valve", "Steam", "apps", "480")
+	if app == nil || app.Get("LaunchOptions") != nil {
+		t.Errorf("Expected LaunchOptions to be removed, got %+v", app)
+	}
+	if err := SetLaunchOptions(root, "730", ""); err != nil {
+		t.Fatalf("SetLaunchOptions failed for a missing app: %v", err)
+	}
+
+	// Test a document that is not a localconfig.vdf
+	other, err := ParseVDF(strings.NewReader(`"AppState" { "appid" "480" }`))
+	if err != nil {
+		t.Fatalf("ParseVDF failed: %v", err)
+	}
+	if _, err := GetLaunchOptions(other, "480"); err == nil {
+		t.Fatalf("GetLaunchOptions was expected to fail but succeeded")
+	}
+}
@@ -0,0 +1,176 @@
+package steam
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"gbe_fork_helper/config"
+)
+
+// ErrNoMetadata is returned by a MetadataProvider that cannot supply the
+// requested metadata, so that the next provider in a chain is tried.
+var ErrNoMetadata = errors.New("metadata not available from this provider")
+
+// Achievement describes an achievement in the format of gbe_fork's achievements.json.
+type Achievement struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Description string `json:"description"`
+	Hidden      int    `json:"hidden"`
+	Icon        string `json:"icon"`
+	IconGray    string `json:"icon_gray"`
+}
+
+// Leaderboard describes a leaderboard in the format of gbe_fork's leaderboards.txt.
+type Leaderboard struct {
+	Name string `json:"name"`
+	// SortMethod is 0 for none, 1 for ascending and 2 for descending.
+	SortMethod int `json:"sort_method"`
+	// DisplayType is 0 for none, 1 for numeric, 2 for seconds and 3 for milliseconds.
+	DisplayType int `json:"display_type"`
+}
+
+// Stat describes a stat in the format of gbe_fork's stats.json.
+type Stat struct {
+	Name string `json:"name"`
+	// Type is int, float or avgrate.
+	Type    string `json:"type"`
+	Default string `json:"default"`
+	Global  string `json:"global"`
+}
+
+// InventoryItem is an inventory item definition in the format of gbe_fork's
+// items.json: the item's properties as strings, including itemdefid.
+type InventoryItem map[string]string
+
+// MetadataProvider supplies app metadata from one source.
+type MetadataProvider interface {
+	// ID identifies the provider in the configured priority order.
+	ID() string
+	AppName(appID string) (string, error)
+	DLCs(appID string) ([]string, error)
+	Achievements(appID string) ([]Achievement, error)
+	Depots(appID string) ([]string, error)
+	Languages(appID string) ([]string, error)
+	Leaderboards(appID string) ([]Leaderboard, error)
+	Stats(appID string) ([]Stat, error)
+	Items(appID string) ([]InventoryItem, error)
+}
+
+// ProviderChain queries its providers in order, returning the first answer.
+type ProviderChain []MetadataProvider
+
+// first runs query against each provider until one succeeds.
+func first[T any](c ProviderChain, what, appID string, query func(MetadataProvider) (T, error)) (T, error) {
+	var errs []error
+	for _, p := range c {
+		result, err := query(p)
+		if err == nil {
+			return result, nil
+		}
+		if !errors.Is(err, ErrNoMetadata) && !errors.Is(err, ErrAppNotFound) {
+			errs = append(errs, fmt.Errorf("%s: %w", p.ID(), err))
+		}
+	}
+	var zero T
+	if len(errs) == 0 {
+		return zero, fmt.Errorf("no provider has %s for AppID %s: %w", what, appID, ErrNoMetadata)
+	}
+	return zero, fmt.Errorf("failed to get %s for AppID %s: %w", what, appID, errors.Join(errs...))
+}
+
+func (c ProviderChain) ID() string {
+	ids := make([]string, len(c))
+	for i, p := range c {
+		ids[i] = p.ID()
+	}
+	return strings.Join(ids, ",")
+}
+
+func (c ProviderChain) AppName(appID string) (string, error) {
+	return first(c, "name", appID, func(p MetadataProvider) (string, error) { return p.AppName(appID) })
+}
+
+func (c ProviderChain) DLCs(appID string) ([]string, error) {
+	return first(c, "DLCs", appID, func(p MetadataProvider) ([]string, error) { return p.DLCs(appID) })
+}
+
+func (c ProviderChain) Achievements(appID string) ([]Achievement, error) {
+	return first(c, "achievements", appID, func(p MetadataProvider) ([]Achievement, error) { return p.Achievements(appID) })
+}
+
+func (c ProviderChain) Depots(appID string) ([]string, error) {
+	return first(c, "depots", appID, func(p MetadataProvider) ([]string, error) { return p.Depots(appID) })
+}
+
+func (c ProviderChain) Languages(appID string) ([]string, error) {
+	return first(c, "languages", appID, func(p MetadataProvider) ([]string, error) { return p.Languages(appID) })
+}
+
+func (c ProviderChain) Leaderboards(appID string) ([]Leaderboard, error) {
+	return first(c, "leaderboards", appID, func(p MetadataProvider) ([]Leaderboard, error) { return p.Leaderboards(appID) })
+}
+
+func (c ProviderChain) Stats(appID string) ([]Stat, error) {
+	return first(c, "stats", appID, func(p MetadataProvider) ([]Stat, error) { return p.Stats(appID) })
+}
+
+func (c ProviderChain) Items(appID string) ([]InventoryItem, error) {
+	return first(c, "items", appID, func(p MetadataProvider) ([]InventoryItem, error) { return p.Items(appID) })
+}
+
+// NewProvider creates the provider with the given ID.
+func NewProvider(id string, profile *config.Profile) (MetadataProvider, error) {
+	switch id {
+	case "override":
+		return newOverrideProvider()
+	case "appinfo":
+		return AppInfoProvider{}, nil
+	case "store":
+		return StoreProvider{}, nil
+	case "webapi":
+		return WebAPIProvider{Key: profile.WebAPIKey}, nil
+	default:
+		return nil, fmt.Errorf("unknown metadata provider: '%s'. Valid providers: %s", id, strings.Join(config.DefaultMetadataProviders, ", "))
+	}
+}
+
+// NewProviderChain creates a chain of the providers with the given IDs, in order.
+func NewProviderChain(ids []string, profile *config.Profile) (ProviderChain, error) {
+	var chain ProviderChain
+	for _, id := range ids {
+		p, err := NewProvider(strings.TrimSpace(id), profile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, p)
+	}
+	return chain, nil
+}
+
+var (
+	defaultProvider     MetadataProvider
+	defaultProviderOnce sync.Once
+)
+
+// DefaultProvider returns the provider chain configured in the user's
+// profile, falling back to the default order if the profile is invalid.
+func DefaultProvider() MetadataProvider {
+	defaultProviderOnce.Do(func() {
+		profile, err := config.LoadProfile()
+		if err != nil {
+			log.Printf("WARN: %v. Using default metadata providers.", err)
+			profile = &config.Profile{MetadataProviders: config.DefaultMetadataProviders}
+		}
+		chain, err := NewProviderChain(profile.MetadataProviders, profile)
+		if err != nil {
+			log.Printf("WARN: %v. Using default metadata providers.", err)
+			chain, _ = NewProviderChain(config.DefaultMetadataProviders, profile)
+		}
+		defaultProvider = chain
+	})
+	return defaultProvider
+}
@@ -0,0 +1,72 @@
+package steam
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// failingProvider returns err for every query.
+type failingProvider struct {
+	err error
+}
+
+func (p failingProvider) ID() string { return "failing" }
+
+func (p failingProvider) AppName(appID string) (string, error) { return "", p.err }
+
+func (p failingProvider) DLCs(appID string) ([]string, error) { return nil, p.err }
+
+func (p failingProvider) Achievements(appID string) ([]Achievement, error) { return nil, p.err }
+
+func (p failingProvider) Depots(appID string) ([]string, error) { return nil, p.err }
+
+func (p failingProvider) Languages(appID string) ([]string, error) { return nil, p.err }
+
+func (p failingProvider) Leaderboards(appID string) ([]Leaderboard, error) { return nil, p.err }
+
+func (p failingProvider) Stats(appID string) ([]Stat, error) { return nil, p.err }
+
+func (p failingProvider) Items(appID string) ([]InventoryItem, error) { return nil, p.err }
+
+func TestProviderChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), OverrideFileName)
+	content := `{"480": {"name": "Spacewar (override)", "dlcs": []}, "570": {"languages": ["english"]}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	override, err := LoadOverrideProvider(path)
+	if err != nil {
+		t.Fatalf("LoadOverrideProvider failed: %v", err)
+	}
+
+	chain := ProviderChain{failingProvider{err: ErrNoMetadata}, override}
+	name, err := chain.AppName("480")
+	if err != nil {
+		t.Fatalf("AppName failed: %v", err)
+	}
+	if name != "Spacewar (override)" {
+		t.Errorf("Expected override name, got %q", name)
+	}
+
+	// An explicitly empty list is an answer, not a miss
+	dlcs, err := chain.DLCs("480")
+	if err != nil || dlcs == nil || len(dlcs) != 0 {
+		t.Errorf("Expected empty DLC list, got %q, %v", dlcs, err)
+	}
+
+	languages, err := chain.Languages("570")
+	if err != nil || !reflect.DeepEqual(languages, []string{"english"}) {
+		t.Errorf("Expected languages [english], got %q, %v", languages, err)
+	}
+
+	// Errors other than missing metadata are reported when no provider answers
+	chain = ProviderChain{failingProvider{err: errors.New("network down")}, override}
+	_, err = chain.AppName("10")
+	if err == nil || !strings.Contains(err.Error(), "network down") {
+		t.Errorf("Expected error mentioning 'network down', got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package steam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gbe_fork_helper/config"
+)
+
+// OverrideFileName is the user-maintained metadata file inside GbeDir.
+const OverrideFileName = "metadata_overrides.json"
+
+// appOverride is the metadata of one app in the override file. Missing
+// fields defer to the next provider.
+type appOverride struct {
+	Name         string          `json:"name,omitempty"`
+	DLCs         []string        `json:"dlcs,omitempty"`
+	Achievements []Achievement   `json:"achievements,omitempty"`
+	Depots       []string        `json:"depots,omitempty"`
+	Languages    []string        `json:"languages,omitempty"`
+	Leaderboards []Leaderboard   `json:"leaderboards,omitempty"`
+	Stats        []Stat          `json:"stats,omitempty"`
+	Items        []InventoryItem `json:"items,omitempty"`
+}
+
+// OverrideProvider serves app metadata from the user-maintained
+// metadata_overrides.json, keyed by AppID. It implements MetadataProvider.
+type OverrideProvider struct {
+	apps map[string]appOverride
+}
+
+// newOverrideProvider loads the override file. A missing file yields an empty provider.
+func newOverrideProvider() (*OverrideProvider, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return LoadOverrideProvider(filepath.Join(homeDir, config.GbeDir, OverrideFileName))
+}
+
+// LoadOverrideProvider loads an override file from path.
+func LoadOverrideProvider(path string) (*OverrideProvider, error) {
+	p := &OverrideProvider{apps: make(map[string]appOverride)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	if err := json.Unmarshal(data, &p.apps); err != nil {
+		return nil, fmt.Errorf("failed to decode '%s': %w", path, err)
+	}
+	return p, nil
+}
+
+func (*OverrideProvider) ID() string { return "override" }
+
+func (p *OverrideProvider) AppName(appID string) (string, error) {
+	if name := p.apps[appID].Name; name != "" {
+		return name, nil
+	}
+	return "", ErrNoMetadata
+}
+
+func (p *OverrideProvider) DLCs(appID string) ([]string, error) {
+	if dlcs := p.apps[appID].DLCs; dlcs != nil {
+		return dlcs, nil
+	}
+	return nil, ErrNoMetadata
+}
+
+func (p *OverrideProvider) Achievements(appID string) ([]Achievement, error) {
+	if achievements := p.apps[appID].Achievements; achievements != nil {
+		return achievements, nil
+	}
+	return nil, ErrNoMetadata
+}
+
+func (p *OverrideProvider) Depots(appID string) ([]string, error) {
+	if depots := p.apps[appID].Depots; depots != nil {
+		return depots, nil
+	}
+	return nil, ErrNoMetadata
+}
+
+func (p *OverrideProvider) Languages(appID string) ([]string, error) {
+	if languages := p.apps[appID].Languages; languages != nil {
+		return languages, nil
+	}
+	return nil, ErrNoMetadata
+}
+
+func (p *OverrideProvider) Leaderboards(appID string) ([]Leaderboard, error) {
+	if leaderboards := p.apps[appID].Leaderboards; leaderboards != nil {
+		return leaderboards, nil
+	}
+	return nil, ErrNoMetadata
+}
+
+func (p *OverrideProvider) Stats(appID string) ([]Stat, error) {
+	if stats := p.apps[appID].Stats; stats != nil {
+		return stats, nil
+	}
+	return nil, ErrNoMetadata
+}
+
+func (p *OverrideProvider) Items(appID string) ([]InventoryItem, error) {
+	if items := p.apps[appID].Items; items != nil {
+		return items, nil
+	}
+	return nil, ErrNoMetadata
+}
@@ -1,43 +1,39 @@
 package steam
 
 import (
-	"encoding/json"
 	"fmt"
-	"gbe_fork_helper/config"
-	"io"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
-	"strings" // Added for strings.Builder
+	"slices"
+	"strings"
 )
 
 // fetchAppName gets the app name for a Steam AppID.
+// The metadata providers configured in the profile are queried in order.
 func FetchAppName(appID string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("%s/appdetails?appids=%s&filters=basic", config.SteamStoreAPI, appID))
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch app details: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var result map[string]struct {
-		Data struct {
-			Name string `json:"name"`
-		} `json:"data"`
-	}
+	return DefaultProvider().AppName(appID)
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode JSON: %w", err)
-	}
-	if appData, ok := result[appID]; ok {
-		return appData.Data.Name, nil
-	}
-	return "", fmt.Errorf("app details not found for AppID %s", appID)
+// DLCOptions holds the optional settings for FetchDLCs.
+type DLCOptions struct {
+	// Overrides are merged into the fetched DLCs.
+	Overrides DLCOverrides
+	// UnlockAll makes the emulator report every DLC as owned, not only the listed ones.
+	UnlockAll bool
+	// AppPaths maps DLC AppIDs to their install folders, relative to the steam_api file.
+	AppPaths map[string]string
 }
 
 // fetchDLCs fetches DLCs for a given AppID.
-func FetchDLCs(appID, libraryPath string) error {
+func FetchDLCs(appID, libraryPath string, opts DLCOptions) error {
+	return writeDLCs(appID, libraryPath, DefaultProvider(), opts)
+}
+
+// writeDLCs writes steam_appid.txt and rewrites configs.app.ini from the
+// DLCs the provider knows and the options. When nothing is left to
+// configure, configs.app.ini is removed, unless the DLCs could not be fetched.
+func writeDLCs(appID, libraryPath string, provider MetadataProvider, opts DLCOptions) error {
 	log.Printf("INFO: Fetching DLCs for AppID %s in library path %s...", appID, libraryPath)
 
 	// Write steam_appid.txt
@@ -54,43 +50,72 @@ func FetchDLCs(appID, libraryPath string) error {
 	}
 	configsAppIniPath := filepath.Join(steamSettingsDir, "configs.app.ini")
 
+	unlockAll := 0
+	if opts.UnlockAll {
+		unlockAll = 1
+	}
 	var dlcContent strings.Builder
-	dlcContent.WriteString("[app::dlcs]\nunlock_all=0\n")
+	dlcContent.WriteString(fmt.Sprintf("[app::dlcs]\nunlock_all=%d\n", unlockAll))
 
-	// Fetch DLCs
+	// Options that are worth writing even without any DLCs listed
+	configured := opts.UnlockAll || len(opts.AppPaths) > 0
 
-	dlcURL := fmt.Sprintf("https://store.steampowered.com/dlc/%s/random/ajaxgetfilteredrecommendations/?query&count=10000", appID)
-	resp, err := http.Get(dlcURL)
-	if err != nil {
-		return fmt.Errorf("failed to fetch DLCs: %w", err)
+	// Fetch DLCs
+	dlcIDs, fetchErr := provider.DLCs(appID)
+	if fetchErr != nil {
+		if len(opts.Overrides.Add) == 0 && !configured {
+			// Keep the DLCs written by an earlier apply
+			return fetchErr
+		}
+		log.Printf("WARN: %v. Using overrides only.", fetchErr)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+	// Merge the overrides, keeping the list ordered for stable output
+	for id := range opts.Overrides.Add {
+		if !slices.Contains(dlcIDs, id) {
+			dlcIDs = append(dlcIDs, id)
+		}
 	}
+	dlcIDs = slices.DeleteFunc(dlcIDs, opts.Overrides.excluded)
+	SortAppIDs(dlcIDs)
 
-	re := regexp.MustCompile(`data-ds-appid=\\"(\d+)`)
-	matches := re.FindAllStringSubmatch(string(body), -1)
-
-	if len(matches) == 0 {
+	if len(dlcIDs) == 0 {
 		log.Printf("WARN: No DLCs found for AppID %s.", appID)
-		return nil
+		if !configured {
+			if fetchErr != nil {
+				return nil
+			}
+			// Nothing is left to configure, so no stale DLCs may stay unlocked
+			if err := os.Remove(configsAppIniPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove configs.app.ini: %w", err)
+			}
+			return nil
+		}
 	}
 
-	uniqueDLCs := make(map[string]struct{})
-	for _, m := range matches {
-		uniqueDLCs[m[1]] = struct{}{}
+	for _, dlcID := range dlcIDs {
+		name, ok := opts.Overrides.Add[dlcID]
+		if !ok || name == "" {
+			var err error
+			name, err = provider.AppName(dlcID)
+			if err != nil {
+				log.Printf("WARN: Failed to get name for DLC %s: %v", dlcID, err)
+				continue
+			}
+		}
+		dlcContent.WriteString(fmt.Sprintf("%s=%s\n", dlcID, name))
 	}
 
-	for dlcID := range uniqueDLCs {
-		name, err := FetchAppName(dlcID)
-		if err != nil {
-			log.Printf("WARN: Failed to get name for DLC %s: %v", dlcID, err)
-			continue
+	if len(opts.AppPaths) > 0 {
+		pathIDs := make([]string, 0, len(opts.AppPaths))
+		for id := range opts.AppPaths {
+			pathIDs = append(pathIDs, id)
+		}
+		SortAppIDs(pathIDs)
+		dlcContent.WriteString("\n[app::paths]\n")
+		for _, id := range pathIDs {
+			dlcContent.WriteString(fmt.Sprintf("%s=%s\n", id, opts.AppPaths[id]))
 		}
-		dlcContent.WriteString(fmt.Sprintf("%s=%s\n", dlcID, name))
 	}
 
 	if err := os.WriteFile(configsAppIniPath, []byte(dlcContent.String()), 0644); err != nil {
@@ -100,3 +125,13 @@ func FetchDLCs(appID, libraryPath string) error {
 
 	return nil
 }
+
+// SortAppIDs sorts AppIDs numerically.
+func SortAppIDs(ids []string) {
+	slices.SortFunc(ids, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+		return strings.Compare(a, b)
+	})
+}
@@ -0,0 +1,67 @@
+package steam
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteDLCs(t *testing.T) {
+	overridePath := filepath.Join(t.TempDir(), OverrideFileName)
+	content := `{"480": {"dlcs": ["481", "482"]}, "481": {"name": "Soundtrack"}, "482": {"name": "Artbook"}}`
+	if err := os.WriteFile(overridePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := LoadOverrideProvider(overridePath)
+	if err != nil {
+		t.Fatalf("LoadOverrideProvider failed: %v", err)
+	}
+	libraryPath := t.TempDir()
+	appConfigPath := filepath.Join(libraryPath, "steam_settings", "configs.app.ini")
+	readAppConfig := func() string {
+		data, err := os.ReadFile(appConfigPath)
+		if os.IsNotExist(err) {
+			return ""
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// Test unlock_all with app paths
+	opts := DLCOptions{UnlockAll: true, AppPaths: map[string]string{"481": "dlc/soundtrack"}}
+	if err := writeDLCs("480", libraryPath, provider, opts); err != nil {
+		t.Fatalf("writeDLCs failed: %v", err)
+	}
+	if content := readAppConfig(); !strings.Contains(content, "unlock_all=1") || !strings.Contains(content, "[app::paths]\n481=dlc/soundtrack") {
+		t.Fatalf("Expected unlock_all=1 and the app path, got %q", content)
+	}
+
+	// Test that options no longer given are dropped
+	if err := writeDLCs("480", libraryPath, provider, DLCOptions{}); err != nil {
+		t.Fatalf("writeDLCs failed: %v", err)
+	}
+	expected := "[app::dlcs]\nunlock_all=0\n481=Soundtrack\n482=Artbook\n"
+	if content := readAppConfig(); content != expected {
+		t.Errorf("Expected %q, got %q", expected, content)
+	}
+
+	// Test that the DLCs are kept when they cannot be fetched
+	if err := writeDLCs("480", libraryPath, failingProvider{err: ErrNoMetadata}, DLCOptions{}); err == nil {
+		t.Errorf("writeDLCs was expected to fail but succeeded")
+	}
+	if content := readAppConfig(); content != expected {
+		t.Errorf("Expected the earlier DLCs to be kept, got %q", content)
+	}
+
+	// Test that excluding every DLC removes configs.app.ini
+	opts = DLCOptions{Overrides: DLCOverrides{Exclude: []string{"481", "482"}}}
+	if err := writeDLCs("480", libraryPath, provider, opts); err != nil {
+		t.Fatalf("writeDLCs failed: %v", err)
+	}
+	if _, err := os.Stat(appConfigPath); !os.IsNotExist(err) {
+		t.Errorf("Expected configs.app.ini to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package steam
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"gbe_fork_helper/config"
+)
+
+// storeLanguages maps the language names of the store website to the API
+// language names gbe_fork uses.
+var storeLanguages = map[string]string{
+	"arabic":                  "arabic",
+	"bulgarian":               "bulgarian",
+	"czech":                   "czech",
+	"danish":                  "danish",
+	"dutch":                   "dutch",
+	"english":                 "english",
+	"finnish":                 "finnish",
+	"french":                  "french",
+	"german":                  "german",
+	"greek":                   "greek",
+	"hungarian":               "hungarian",
+	"indonesian":              "indonesian",
+	"italian":                 "italian",
+	"japanese":                "japanese",
+	"korean":                  "koreana",
+	"norwegian":               "norwegian",
+	"polish":                  "polish",
+	"portuguese - brazil":     "brazilian",
+	"portuguese - portugal":   "portuguese",
+	"romanian":                "romanian",
+	"russian":                 "russian",
+	"simplified chinese":      "schinese",
+	"spanish - latin america": "latam",
+	"spanish - spain":         "spanish",
+	"swedish":                 "swedish",
+	"thai":                    "thai",
+	"traditional chinese":     "tchinese",
+	"turkish":                 "turkish",
+	"ukrainian":               "ukrainian",
+	"vietnamese":              "vietnamese",
+}
+
+// StoreProvider reads app metadata from the Steam store and community
+// websites. It implements MetadataProvider.
+type StoreProvider struct{}
+
+func (StoreProvider) ID() string { return "store" }
+
+// appDetails fetches the store's appdetails for an app.
+func (StoreProvider) appDetails(appID, filters string) (*struct {
+	Name               string `json:"name"`
+	SupportedLanguages string `json:"supported_languages"`
+}, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/appdetails?appids=%s&filters=%s", config.SteamStoreAPI, appID, filters))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch app details: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Name               string `json:"name"`
+			SupportedLanguages string `json:"supported_languages"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	if appData, ok := result[appID]; ok && appData.Success {
+		return &appData.Data, nil
+	}
+	return nil, fmt.Errorf("app details not found for AppID %s", appID)
+}
+
+func (s StoreProvider) AppName(appID string) (string, error) {
+	details, err := s.appDetails(appID, "basic")
+	if err != nil {
+		return "", err
+	}
+	return details.Name, nil
+}
+
+func (StoreProvider) DLCs(appID string) ([]string, error) {
+	dlcURL := fmt.Sprintf("https://store.steampowered.com/dlc/%s/random/ajaxgetfilteredrecommendations/?query&count=10000", appID)
+	resp, err := http.Get(dlcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch DLCs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	re := regexp.MustCompile(`data-ds-appid=\\"(\d+)`)
+	matches := re.FindAllStringSubmatch(string(body), -1)
+
+	var dlcIDs []string
+	uniqueDLCs := make(map[string]struct{})
+	for _, m := range matches {
+		if _, ok := uniqueDLCs[m[1]]; !ok {
+			uniqueDLCs[m[1]] = struct{}{}
+			dlcIDs = append(dlcIDs, m[1])
+		}
+	}
+	return dlcIDs, nil
+}
+
+func (StoreProvider) Achievements(appID string) ([]Achievement, error) {
+	return nil, ErrNoMetadata
+}
+
+func (StoreProvider) Depots(appID string) ([]string, error) {
+	return nil, ErrNoMetadata
+}
+
+func (s StoreProvider) Languages(appID string) ([]string, error) {
+	details, err := s.appDetails(appID, "basic")
+	if err != nil {
+		return nil, err
+	}
+	// The list is HTML such as "English<strong>*</strong>, French<br>..."
+	text := regexp.MustCompile(`<br>.*$`).ReplaceAllString(details.SupportedLanguages, "")
+	text = regexp.MustCompile(`<[^>]*>|\*`).ReplaceAllString(text, "")
+	var languages []string
+	for _, name := range strings.Split(text, ",") {
+		if language, ok := storeLanguages[strings.ToLower(strings.TrimSpace(name))]; ok {
+			languages = append(languages, language)
+		}
+	}
+	if len(languages) == 0 {
+		return nil, ErrNoMetadata
+	}
+	return languages, nil
+}
+
+// leaderboardsXML is the response of the community site's leaderboard list.
+type leaderboardsXML struct {
+	Error        string `xml:"error"`
+	Leaderboards []struct {
+		Name        string `xml:"name"`
+		SortMethod  int    `xml:"sortmethod"`
+		DisplayType int    `xml:"displaytype"`
+	} `xml:"leaderboard"`
+}
+
+func (StoreProvider) Stats(appID string) ([]Stat, error) {
+	return nil, ErrNoMetadata
+}
+
+func (StoreProvider) Items(appID string) ([]InventoryItem, error) {
+	return nil, ErrNoMetadata
+}
+
+func (StoreProvider) Leaderboards(appID string) ([]Leaderboard, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/stats/%s/leaderboards/?xml=1", config.SteamCommunityURL, appID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch leaderboards: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch leaderboards: %s", resp.Status)
+	}
+
+	var result leaderboardsXML
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		// Apps without stats get an HTML page instead
+		return nil, ErrNoMetadata
+	}
+	if result.Error != "" {
+		return nil, ErrNoMetadata
+	}
+	leaderboards := make([]Leaderboard, 0, len(result.Leaderboards))
+	for _, lb := range result.Leaderboards {
+		leaderboards = append(leaderboards, Leaderboard{Name: lb.Name, SortMethod: lb.SortMethod, DisplayType: lb.DisplayType})
+	}
+	return leaderboards, nil
+}
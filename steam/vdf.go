@@ -0,0 +1,349 @@
+package steam
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeyValue is a node of a text VDF (KeyValues) document such as
+// libraryfolders.vdf or an appmanifest. A node is either a string value or an
+// object whose children are kept in file order, duplicates included.
+type KeyValue struct {
+	Key      string
+	Value    string
+	Children []*KeyValue
+	// Condition is a platform conditional such as [$WIN32] following the
+	// entry, kept so that rewritten files are unchanged.
+	Condition string
+
+	// raw is the value as it was read, escapes included, and parsed the
+	// value it decoded to. An unchanged value is written back as read.
+	raw, parsed string
+}
+
+// IsObject reports whether the node holds children rather than a string value.
+func (kv *KeyValue) IsObject() bool {
+	return kv.Children != nil
+}
+
+// Get returns the first child with the given key. Keys are compared
+// case-insensitively, as Steam does.
+func (kv *KeyValue) Get(key string) *KeyValue {
+	if kv == nil {
+		return nil
+	}
+	for _, child := range kv.Children {
+		if strings.EqualFold(child.Key, key) {
+			return child
+		}
+	}
+	return nil
+}
+
+// GetPath follows a sequence of keys, returning nil if any is missing.
+func (kv *KeyValue) GetPath(keys ...string) *KeyValue {
+	for _, key := range keys {
+		kv = kv.Get(key)
+	}
+	return kv
+}
+
+// String returns the value of the child at the given key path, or "" if it is missing.
+func (kv *KeyValue) String(keys ...string) string {
+	if node := kv.GetPath(keys...); node != nil {
+		return node.Value
+	}
+	return ""
+}
+
+// vdfParser tokenizes and parses text VDF.
+type vdfParser struct {
+	r    *bufio.Reader
+	line int
+	// raw is the last quoted string as it was read
+	raw strings.Builder
+}
+
+// ParseVDF parses a text VDF document. The returned root node has the
+// document's top-level entries as children.
+func ParseVDF(r io.Reader) (*KeyValue, error) {
+	p := &vdfParser{r: bufio.NewReader(r), line: 1}
+	root := &KeyValue{Children: []*KeyValue{}}
+	if err := p.parseChildren(root, false); err != nil {
+		return nil, fmt.Errorf("line %d: %w", p.line, err)
+	}
+	return root, nil
+}
+
+// ParseVDFFile parses the text VDF file at path.
+func ParseVDFFile(path string) (*KeyValue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	root, err := ParseVDF(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %w", path, err)
+	}
+	return root, nil
+}
+
+// parseChildren reads key/value pairs into parent until a closing brace or,
+// for the document root, the end of input.
+func (p *vdfParser) parseChildren(parent *KeyValue, nested bool) error {
+	for {
+		token, quoted, err := p.next()
+		if err == io.EOF {
+			if nested {
+				return fmt.Errorf("unexpected end of file, missing '}'")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !quoted && token == "}" {
+			if !nested {
+				return fmt.Errorf("unexpected '}'")
+			}
+			return nil
+		}
+		if !quoted && token == "{" {
+			return fmt.Errorf("unexpected '{'")
+		}
+		if !quoted && isCondition(token) {
+			// Conditionals follow the entry they apply to
+			if len(parent.Children) == 0 {
+				return fmt.Errorf("unexpected conditional %s", token)
+			}
+			parent.Children[len(parent.Children)-1].Condition = token
+			continue
+		}
+
+		node := &KeyValue{Key: token}
+		value, quoted, err := p.next()
+		if err == nil && !quoted && isCondition(value) {
+			// Objects may carry their conditional before the opening brace
+			node.Condition = value
+			value, quoted, err = p.next()
+		}
+		if err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("missing value for key '%s'", token)
+			}
+			return err
+		}
+		if !quoted && value == "{" {
+			node.Children = []*KeyValue{}
+			if err := p.parseChildren(node, true); err != nil {
+				return err
+			}
+		} else if !quoted && (value == "}" || isCondition(value)) {
+			return fmt.Errorf("missing value for key '%s'", token)
+		} else {
+			node.Value = value
+			if quoted {
+				node.raw, node.parsed = p.raw.String(), value
+			}
+		}
+		parent.Children = append(parent.Children, node)
+	}
+}
+
+// isCondition reports whether an unquoted token is a conditional such as [$WIN32].
+func isCondition(token string) bool {
+	return strings.HasPrefix(token, "[")
+}
+
+// next returns the next token, skipping whitespace and comments. Braces and
+// conditionals such as [$WIN32] are returned as unquoted tokens.
+func (p *vdfParser) next() (string, bool, error) {
+	for {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			return "", false, err
+		}
+		switch {
+		case c == '\n':
+			p.line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '/':
+			if next, err := p.r.Peek(1); err == nil && next[0] == '/' {
+				if _, err := p.r.ReadString('\n'); err != nil && err != io.EOF {
+					return "", false, err
+				}
+				p.line++
+				continue
+			}
+			return p.unquoted(c)
+		case c == '[':
+			condition, err := p.r.ReadString(']')
+			if err != nil {
+				return "", false, fmt.Errorf("unterminated conditional")
+			}
+			return "[" + condition, false, nil
+		case c == '{' || c == '}':
+			return string(c), false, nil
+		case c == '"':
+			return p.quoted()
+		default:
+			return p.unquoted(c)
+		}
+	}
+}
+
+// quoted reads a quoted string after its opening quote.
+func (p *vdfParser) quoted() (string, bool, error) {
+	var b strings.Builder
+	p.raw.Reset()
+	for {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			return "", true, fmt.Errorf("unterminated string")
+		}
+		if c != '"' {
+			p.raw.WriteByte(c)
+		}
+		switch c {
+		case '"':
+			return b.String(), true, nil
+		case '\\':
+			escaped, err := p.r.ReadByte()
+			if err != nil {
+				return "", true, fmt.Errorf("unterminated string")
+			}
+			p.raw.WriteByte(escaped)
+			switch escaped {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"':
+				b.WriteByte(escaped)
+			default:
+				// Unknown escapes such as \x are kept as read
+				b.WriteByte('\\')
+				b.WriteByte(escaped)
+			}
+		case '\n':
+			p.line++
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// unquoted reads a bare token starting with first.
+func (p *vdfParser) unquoted(first byte) (string, bool, error) {
+	b := []byte{first}
+	for {
+		c, err := p.r.ReadByte()
+		if err == io.EOF {
+			return string(b), false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '"' || c == '{' || c == '}' {
+			p.r.UnreadByte()
+			return string(b), false, nil
+		}
+		b = append(b, c)
+	}
+}
+
+// Set sets the string value of the first child with the given key, adding
+// the child if it is missing.
+func (kv *KeyValue) Set(key, value string) {
+	if child := kv.Get(key); child != nil {
+		child.Value = value
+		child.Children = nil
+		return
+	}
+	kv.Children = append(kv.Children, &KeyValue{Key: key, Value: value})
+}
+
+// Object returns the first child object with the given key, adding an empty
+// one if it is missing.
+func (kv *KeyValue) Object(key string) *KeyValue {
+	if child := kv.Get(key); child != nil && child.IsObject() {
+		return child
+	}
+	child := &KeyValue{Key: key, Children: []*KeyValue{}}
+	kv.Children = append(kv.Children, child)
+	return child
+}
+
+// Remove deletes every child with the given key, reporting whether any existed.
+func (kv *KeyValue) Remove(key string) bool {
+	removed := false
+	children := kv.Children[:0]
+	for _, child := range kv.Children {
+		if strings.EqualFold(child.Key, key) {
+			removed = true
+			continue
+		}
+		children = append(children, child)
+	}
+	kv.Children = children
+	return removed
+}
+
+// vdfEscaper escapes strings the way Steam writes them.
+var vdfEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+// WriteVDF writes the children of root as a text VDF document, formatted as
+// the Steam client formats its own files.
+func WriteVDF(w io.Writer, root *KeyValue) error {
+	bw := bufio.NewWriter(w)
+	writeChildren(bw, root.Children, 0)
+	return bw.Flush()
+}
+
+// WriteVDFFile writes root to path, replacing the file atomically.
+func WriteVDFFile(path string, root *KeyValue) error {
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if err := WriteVDF(f, root); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// writeChildren writes nodes at the given indentation depth.
+func writeChildren(w *bufio.Writer, nodes []*KeyValue, depth int) {
+	indent := strings.Repeat("\t", depth)
+	for _, node := range nodes {
+		condition := ""
+		if node.Condition != "" {
+			condition = "\t\t" + node.Condition
+		}
+		if node.IsObject() {
+			fmt.Fprintf(w, "%s\"%s\"%s\n%s{\n", indent, vdfEscaper.Replace(node.Key), condition, indent)
+			writeChildren(w, node.Children, depth+1)
+			fmt.Fprintf(w, "%s}\n", indent)
+			continue
+		}
+		value := vdfEscaper.Replace(node.Value)
+		if node.raw != "" && node.Value == node.parsed {
+			// Keep unknown escapes, which would otherwise gain a backslash
+			value = node.raw
+		}
+		fmt.Fprintf(w, "%s\"%s\"\t\t\"%s\"%s\n", indent, vdfEscaper.Replace(node.Key), value, condition)
+	}
+}
@@ -0,0 +1,126 @@
+package steam
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVDF(t *testing.T) {
+	input := `// Written by Steam
+"libraryfolders"
+{
+	"0"
+	{
+		"path"		"/home/user/.local/share/Steam"
+		"apps"
+		{
+			"480"		"123456"
+		}
+	}
+	"1"
+	{
+		"path"		"/mnt/games/Steam\\Library \"2\""
+		"apps"		{ }
+	}
+	unquoted value [$WIN32]
+}
+`
+	root, err := ParseVDF(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseVDF failed: %v", err)
+	}
+
+	folders := root.Get("LibraryFolders")
+	if folders == nil || len(folders.Children) != 3 {
+		t.Fatalf("Expected 3 entries in libraryfolders, got %+v", folders)
+	}
+	if got := folders.String("0", "path"); got != "/home/user/.local/share/Steam" {
+		t.Errorf("Expected first library path, got %q", got)
+	}
+	if got := folders.String("1", "path"); got != `/mnt/games/Steam\Library "2"` {
+		t.Errorf("Expected escaped library path, got %q", got)
+	}
+	if got := folders.String("0", "apps", "480"); got != "123456" {
+		t.Errorf("Expected app size %q, got %q", "123456", got)
+	}
+	if apps := folders.GetPath("1", "apps"); apps == nil || !apps.IsObject() || len(apps.Children) != 0 {
+		t.Errorf("Expected empty apps object, got %+v", apps)
+	}
+	if got := folders.String("unquoted"); got != "value" {
+		t.Errorf("Expected unquoted value %q, got %q", "value", got)
+	}
+
+	// Test malformed input
+	if _, err := ParseVDF(strings.NewReader(`"AppState" { "appid" "480"`)); err == nil {
+		t.Fatalf("ParseVDF was expected to fail for unterminated object but succeeded")
+	}
+}
+
+func TestWriteVDFRoundTrip(t *testing.T) {
+	input := "\"UserLocalConfigStore\"\n" +
+		"{\n" +
+		"\t\"Software\"\n" +
+		"\t{\n" +
+		"\t\t\"Valve\"\n" +
+		"\t\t{\n" +
+		"\t\t\t\"Steam\"\n" +
+		"\t\t\t{\n" +
+		"\t\t\t\t\"apps\"\n" +
+		"\t\t\t\t{\n" +
+		"\t\t\t\t\t\"480\"\n" +
+		"\t\t\t\t\t{\n" +
+		"\t\t\t\t\t\t\"LastPlayed\"\t\t\"1700000000\"\n" +
+		"\t\t\t\t\t\t\"LaunchOptions\"\t\t\"PROTON_LOG=1 \\\"%command%\\\" -path C:\\\\Games\"\n" +
+		"\t\t\t\t\t\t\"WinPath\"\t\t\"C:\\xbox\\\\Games\\\\\"\t\t[$WIN32]\n" +
+		"\t\t\t\t\t\t\"cloud\"\t\t[!$OSX]\n" +
+		"\t\t\t\t\t\t{\n" +
+		"\t\t\t\t\t\t}\n" +
+		"\t\t\t\t\t}\n" +
+		"\t\t\t\t\t\"empty\"\n" +
+		"\t\t\t\t\t{\n" +
+		"\t\t\t\t\t}\n" +
+		"\t\t\t\t}\n" +
+		"\t\t\t}\n" +
+		"\t\t}\n" +
+		"\t}\n" +
+		"}\n"
+
+	root, err := ParseVDF(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseVDF failed: %v", err)
+	}
+	app := root.GetPath("UserLocalConfigStore", "Software", "Valve", "Steam", "apps", "480")
+	if got := app.String("LaunchOptions"); got != `PROTON_LOG=1 "%command%" -path C:\Games` {
+		t.Errorf("Unexpected launch options %q", got)
+	}
+	if got := app.String("WinPath"); got != `C:\xbox\Games\` {
+		t.Errorf("Expected unknown escape to be kept, got %q", got)
+	}
+	if got := app.Get("WinPath").Condition; got != "[$WIN32]" {
+		t.Errorf("Expected conditional %q, got %q", "[$WIN32]", got)
+	}
+	if got := app.Get("cloud").Condition; got != "[!$OSX]" {
+		t.Errorf("Expected object conditional %q, got %q", "[!$OSX]", got)
+	}
+
+	var out strings.Builder
+	if err := WriteVDF(&out, root); err != nil {
+		t.Fatalf("WriteVDF failed: %v", err)
+	}
+	if out.String() != input {
+		t.Errorf("Round trip mismatch.\nExpected:\n%s\nGot:\n%s", input, out.String())
+	}
+
+	// Test editing values
+	app.Set("LaunchOptions", "%command% -windowed")
+	app.Set("NewKey", "1")
+	if !app.Remove("LastPlayed") {
+		t.Errorf("Expected LastPlayed to be removed")
+	}
+	if got := app.String("LaunchOptions"); got != "%command% -windowed" {
+		t.Errorf("Expected updated launch options, got %q", got)
+	}
+	if len(app.Children) != 4 || app.Children[3].Key != "NewKey" {
+		t.Errorf("Unexpected children after edit: %+v", app.Children)
+	}
+}
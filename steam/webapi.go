@@ -0,0 +1,175 @@
+package steam
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"gbe_fork_helper/config"
+)
+
+// WebAPIProvider reads app metadata from the Steam Web API, which requires an
+// API key. It implements MetadataProvider.
+type WebAPIProvider struct {
+	Key string
+}
+
+func (WebAPIProvider) ID() string { return "webapi" }
+
+// gameSchema is the response of ISteamUserStats/GetSchemaForGame.
+type gameSchema struct {
+	Game struct {
+		GameName           string `json:"gameName"`
+		AvailableGameStats struct {
+			Achievements []struct {
+				Name         string `json:"name"`
+				DefaultValue int    `json:"defaultvalue"`
+				DisplayName  string `json:"displayName"`
+				Hidden       int    `json:"hidden"`
+				Description  string `json:"description"`
+				Icon         string `json:"icon"`
+				IconGray     string `json:"icongray"`
+			} `json:"achievements"`
+		} `json:"availableGameStats"`
+	} `json:"game"`
+}
+
+// schema fetches the stats and achievements schema of an app.
+func (w WebAPIProvider) schema(appID string) (*gameSchema, error) {
+	if w.Key == "" {
+		return nil, ErrNoMetadata
+	}
+	var schema gameSchema
+	url := fmt.Sprintf("%s/ISteamUserStats/GetSchemaForGame/v2/?appid=%s&l=english", config.SteamWebAPI, appID)
+	if err := w.getJSON(url, "game schema", &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func (w WebAPIProvider) AppName(appID string) (string, error) {
+	schema, err := w.schema(appID)
+	if err != nil {
+		return "", err
+	}
+	if schema.Game.GameName == "" {
+		return "", ErrNoMetadata
+	}
+	return schema.Game.GameName, nil
+}
+
+func (WebAPIProvider) DLCs(appID string) ([]string, error) {
+	return nil, ErrNoMetadata
+}
+
+func (w WebAPIProvider) Achievements(appID string) ([]Achievement, error) {
+	schema, err := w.schema(appID)
+	if err != nil {
+		return nil, err
+	}
+	var achievements []Achievement
+	for _, a := range schema.Game.AvailableGameStats.Achievements {
+		achievements = append(achievements, Achievement{
+			Name:        a.Name,
+			DisplayName: a.DisplayName,
+			Description: a.Description,
+			Hidden:      a.Hidden,
+			Icon:        a.Icon,
+			IconGray:    a.IconGray,
+		})
+	}
+	return achievements, nil
+}
+
+func (WebAPIProvider) Depots(appID string) ([]string, error) {
+	return nil, ErrNoMetadata
+}
+
+func (WebAPIProvider) Languages(appID string) ([]string, error) {
+	return nil, ErrNoMetadata
+}
+
+func (WebAPIProvider) Leaderboards(appID string) ([]Leaderboard, error) {
+	return nil, ErrNoMetadata
+}
+
+// Stats is not answered: the schema has no stat types, and a float stat
+// written as int breaks the game's GetStat calls.
+func (WebAPIProvider) Stats(appID string) ([]Stat, error) {
+	return nil, ErrNoMetadata
+}
+
+// getJSON fetches a Web API URL and decodes its JSON response into v. The
+// key is sent in a header, so that it never appears in URLs or errors.
+func (w WebAPIProvider) getJSON(url, what string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-webapi-key", w.Key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", what, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return fmt.Errorf("Steam Web API key was rejected: %s", resp.Status)
+	default:
+		return fmt.Errorf("failed to fetch %s: %s", what, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	// The item definition archive ends with a NUL byte
+	if err := json.Unmarshal(bytes.TrimRight(body, "\x00"), v); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return nil
+}
+
+func (w WebAPIProvider) Items(appID string) ([]InventoryItem, error) {
+	if w.Key == "" {
+		return nil, ErrNoMetadata
+	}
+	var meta struct {
+		Response struct {
+			Digest string `json:"digest"`
+		} `json:"response"`
+	}
+	url := fmt.Sprintf("%s/IInventoryService/GetItemDefMeta/v1/?appid=%s", config.SteamWebAPI, appID)
+	if err := w.getJSON(url, "item definition digest", &meta); err != nil {
+		return nil, err
+	}
+	if meta.Response.Digest == "" {
+		return nil, ErrNoMetadata
+	}
+
+	var defs []map[string]any
+	url = fmt.Sprintf("%s/IGameInventory/GetItemDefArchive/v0001/?appid=%s&digest=%s", config.SteamWebAPI, appID, meta.Response.Digest)
+	if err := w.getJSON(url, "item definitions", &defs); err != nil {
+		return nil, err
+	}
+	items := make([]InventoryItem, 0, len(defs))
+	for _, def := range defs {
+		item := make(InventoryItem, len(def))
+		for key, value := range def {
+			switch v := value.(type) {
+			case string:
+				item[key] = v
+			case float64:
+				item[key] = strconv.FormatFloat(v, 'f', -1, 64)
+			case nil:
+			default:
+				item[key] = fmt.Sprint(v)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,112 @@
+package steam
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gbe_fork_helper/config"
+)
+
+// WorkshopItem describes a published workshop item.
+type WorkshopItem struct {
+	ID          string
+	Title       string
+	Description string
+	Creator     string
+	FileName    string
+	FileSize    int64
+	PreviewURL  string
+	TimeCreated int64
+	TimeUpdated int64
+	Tags        []string
+}
+
+// publishedFileDetails is the response of ISteamRemoteStorage/GetPublishedFileDetails.
+type publishedFileDetails struct {
+	Response struct {
+		PublishedFileDetails []struct {
+			PublishedFileID string      `json:"publishedfileid"`
+			Result          int         `json:"result"`
+			Creator         string      `json:"creator"`
+			Filename        string      `json:"filename"`
+			FileSize        json.Number `json:"file_size"`
+			PreviewURL      string      `json:"preview_url"`
+			Title           string      `json:"title"`
+			Description     string      `json:"description"`
+			TimeCreated     int64       `json:"time_created"`
+			TimeUpdated     int64       `json:"time_updated"`
+			Tags            []struct {
+				Tag string `json:"tag"`
+			} `json:"tags"`
+		} `json:"publishedfiledetails"`
+	} `json:"response"`
+}
+
+// WorkshopContentDir returns the directory Steam downloads an app's
+// subscribed workshop items to, one folder per item ID.
+func WorkshopContentDir(appID string) (string, error) {
+	app, err := FindApp(appID)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(app.Library, "steamapps", "workshop", "content", appID)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("no workshop content for AppID %s: %w", appID, err)
+	}
+	return dir, nil
+}
+
+// FetchWorkshopItems fetches the details of published workshop items. This
+// endpoint needs no API key. Items that are not found are left out.
+func FetchWorkshopItems(ids []string) (map[string]WorkshopItem, error) {
+	form := url.Values{"itemcount": {strconv.Itoa(len(ids))}}
+	for i, id := range ids {
+		form.Set(fmt.Sprintf("publishedfileids[%d]", i), id)
+	}
+	resp, err := http.PostForm(config.SteamWebAPI+"/ISteamRemoteStorage/GetPublishedFileDetails/v1/", form)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workshop items: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch workshop items: %s", resp.Status)
+	}
+
+	var details publishedFileDetails
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	items := make(map[string]WorkshopItem)
+	for _, d := range details.Response.PublishedFileDetails {
+		// Result 1 is EResult OK
+		if d.Result != 1 {
+			continue
+		}
+		item := WorkshopItem{
+			ID:          d.PublishedFileID,
+			Title:       d.Title,
+			Description: d.Description,
+			Creator:     d.Creator,
+			FileName:    filepath.Base(filepath.FromSlash(d.Filename)),
+			PreviewURL:  d.PreviewURL,
+			TimeCreated: d.TimeCreated,
+			TimeUpdated: d.TimeUpdated,
+		}
+		if d.Filename == "" {
+			item.FileName = ""
+		}
+		// file_size is a string in newer responses and a number in older ones,
+		// both of which decode into a json.Number
+		item.FileSize, _ = d.FileSize.Int64()
+		for _, tag := range d.Tags {
+			item.Tags = append(item.Tags, tag.Tag)
+		}
+		items[item.ID] = item
+	}
+	return items, nil
+}
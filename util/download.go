@@ -0,0 +1,286 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// DownloadOptions controls how a file is downloaded.
+type DownloadOptions struct {
+	// RateLimit caps the transfer speed in bytes per second. Zero means unlimited.
+	RateLimit int64
+	// Retries is how many times an interrupted download is resumed before giving up.
+	Retries int
+}
+
+// DefaultRetries is the number of resume attempts used when DownloadOptions.Retries is zero.
+const DefaultRetries = 3
+
+// downloadFile downloads url to dest. Data is written to dest.part first, and
+// an existing partial file is resumed with an HTTP Range request.
+func DownloadFile(url, dest string, opts DownloadOptions) error {
+	retries := opts.Retries
+	if retries <= 0 {
+		retries = DefaultRetries
+	}
+
+	partPath := dest + ".part"
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			log.Printf("WARN: Download interrupted: %v. Resuming (attempt %d/%d)...", err, attempt, retries)
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		if err = downloadPart(url, partPath, opts); err == nil {
+			os.Remove(partPath + ".etag")
+			return os.Rename(partPath, dest)
+		}
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) && statusErr.code < 500 {
+			break
+		}
+	}
+	return err
+}
+
+// httpStatusError reports an unexpected HTTP response status.
+type httpStatusError struct {
+	code   int
+	status string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status: %s", e.status)
+}
+
+// downloadPart fetches url into partPath, appending to any data already present.
+// The ETag of the partial download is kept in partPath.etag so that a changed
+// remote file restarts the download instead of being spliced onto stale data.
+func downloadPart(url, partPath string, opts DownloadOptions) error {
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if etag, err := os.ReadFile(partPath + ".etag"); err == nil && len(etag) > 0 {
+			req.Header.Set("If-Range", string(etag))
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	total := resp.ContentLength
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if offset > 0 {
+			log.Println("INFO: Server does not support resuming or file changed. Restarting download.")
+		}
+		offset = 0
+		flags |= os.O_TRUNC
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+		if total >= 0 {
+			total += offset
+		}
+		log.Printf("INFO: Resuming download at %s.", FormatBytes(offset))
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file already holds the complete content.
+		if size, ok := contentRangeSize(resp.Header.Get("Content-Range")); ok && size == offset {
+			return nil
+		}
+		os.Remove(partPath)
+		return fmt.Errorf("partial download does not match remote file")
+	default:
+		return &httpStatusError{code: resp.StatusCode, status: resp.Status}
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		if err := os.WriteFile(partPath+".etag", []byte(etag), 0644); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var body io.Reader = resp.Body
+	if opts.RateLimit > 0 {
+		body = &throttledReader{r: body, rate: opts.RateLimit, start: time.Now()}
+	}
+	progress := newProgress(offset, total)
+	defer progress.finish()
+
+	_, err = io.Copy(io.MultiWriter(file, progress), body)
+	return err
+}
+
+// contentRangeSize extracts the complete length from a Content-Range header
+// such as "bytes */1234".
+func contentRangeSize(header string) (int64, bool) {
+	i := strings.LastIndex(header, "/")
+	if i < 0 {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(header[i+1:], 10, 64)
+	return size, err == nil
+}
+
+// throttledReader limits the average read rate to rate bytes per second.
+type throttledReader struct {
+	r     io.Reader
+	rate  int64
+	start time.Time
+	read  int64
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	// Keep individual reads small so the rate stays smooth.
+	if max := t.rate / 10; max > 0 && int64(len(p)) > max {
+		p = p[:max]
+	}
+	n, err := t.r.Read(p)
+	t.read += int64(n)
+	expected := time.Duration(float64(t.read) / float64(t.rate) * float64(time.Second))
+	if wait := expected - time.Since(t.start); wait > 0 {
+		time.Sleep(wait)
+	}
+	return n, err
+}
+
+// progress reports download progress, as a redrawn bar on terminals and
+// as periodic log lines otherwise.
+type progress struct {
+	initial, done, total int64
+	start, last          time.Time
+	tty                  bool
+}
+
+func newProgress(initial, total int64) *progress {
+	now := time.Now()
+	return &progress{
+		initial: initial,
+		done:    initial,
+		total:   total,
+		start:   now,
+		last:    now,
+		tty:     term.IsTerminal(int(os.Stderr.Fd())),
+	}
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	interval := 5 * time.Second
+	if p.tty {
+		interval = 200 * time.Millisecond
+	}
+	if time.Since(p.last) >= interval {
+		p.last = time.Now()
+		p.report()
+	}
+	return len(b), nil
+}
+
+// report prints the current progress.
+func (p *progress) report() {
+	elapsed := time.Since(p.start).Seconds()
+	var speed float64
+	if elapsed > 0 {
+		speed = float64(p.done-p.initial) / elapsed
+	}
+
+	status := FormatBytes(p.done)
+	eta := ""
+	if p.total > 0 {
+		status = fmt.Sprintf("%s / %s (%3.0f%%)", status, FormatBytes(p.total), float64(p.done)*100/float64(p.total))
+		if speed > 0 {
+			remaining := time.Duration(float64(p.total-p.done)/speed) * time.Second
+			eta = fmt.Sprintf(" ETA %s", remaining.Round(time.Second))
+		}
+	}
+	status = fmt.Sprintf("%s at %s/s%s", status, FormatBytes(int64(speed)), eta)
+
+	if !p.tty {
+		log.Printf("INFO: Downloaded %s", status)
+		return
+	}
+	const width = 30
+	bar := strings.Repeat("-", width)
+	if p.total > 0 {
+		filled := int(float64(width) * float64(p.done) / float64(p.total))
+		if filled > width {
+			filled = width
+		}
+		bar = strings.Repeat("=", filled) + strings.Repeat("-", width-filled)
+	}
+	fmt.Fprintf(os.Stderr, "\r[%s] %s\033[K", bar, status)
+}
+
+// finish prints the final progress state.
+func (p *progress) finish() {
+	p.report()
+	if p.tty {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// formatBytes renders a byte count with a binary unit suffix.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// parseByteSize parses sizes such as "500K", "2M" or "1048576" into bytes.
+func ParseByteSize(size string) (int64, error) {
+	s := strings.TrimSpace(strings.ToUpper(size))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+	multiplier := int64(1)
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: '%s'", size)
+	}
+	return int64(value * float64(multiplier)), nil
+}
@@ -0,0 +1,145 @@
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadFileResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "asset", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	dest := filepath.Join(tmpDir, "asset")
+
+	// Simulate an interrupted download
+	if err := os.WriteFile(dest+".part", content[:4000], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DownloadFile(server.URL, dest, DownloadOptions{}); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("Downloaded content mismatch: got %d bytes, expected %d", len(got), len(content))
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=4000-" {
+		t.Errorf("Expected a single request with Range 'bytes=4000-', got %q", ranges)
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Errorf("Expected partial file to be removed, got %v", err)
+	}
+
+	// Test a server error
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	err = DownloadFile(missing.URL, filepath.Join(tmpDir, "missing"), DownloadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected 404 error, got %v", err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"1024": 1024,
+		"500K": 500 << 10,
+		"2M":   2 << 20,
+		"2MiB": 2 << 20,
+		"1.5G": 3 << 29,
+	}
+	for input, expected := range tests {
+		got, err := ParseByteSize(input)
+		if err != nil {
+			t.Errorf("ParseByteSize(%q) failed: %v", input, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("ParseByteSize(%q): expected %d, got %d", input, expected, got)
+		}
+	}
+
+	if _, err := ParseByteSize("fast"); err == nil {
+		t.Errorf("ParseByteSize was expected to fail for 'fast' but succeeded")
+	}
+}
+
+// releaseArchive is a tar.bz2 archive holding release/steamclient.so.
+const releaseArchive = "QlpoOTFBWSZTWalzOpcAAJb/gMqAAEBAAf+AABAAQGonngAICCAAdBKKeih+oGp6jT0jDTUEko0GRoAAA+5fC1hyQHU6SEYTSl8jwqpYYoEIYBl5uSgTUQiKCHyjCDTWOhLOl6yg9O7shlmKb8VIMaFqtbwddXDvxA5vuKuVs6RlYmIgfxdyRThQkKlzOpc="
+
+func TestDownloadAndExtract(t *testing.T) {
+	archive, err := base64.StdEncoding.DecodeString(releaseArchive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken.tar.bz2" {
+			w.Write([]byte("not an archive"))
+			return
+		}
+		http.ServeContent(w, r, "asset", time.Time{}, bytes.NewReader(archive))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "linux_release")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(destDir, "old.so"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Test a failing extraction, which keeps the installed files and the archive
+	err = DownloadAndExtract(server.URL+"/broken.tar.bz2", destDir, "tar.bz2", DownloadOptions{})
+	if err == nil {
+		t.Fatalf("DownloadAndExtract was expected to fail but succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "old.so")); err != nil {
+		t.Errorf("Expected the installed files to be kept, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".downloads", "broken.tar.bz2")); err != nil {
+		t.Errorf("Expected the archive to be kept for the next attempt, got %v", err)
+	}
+
+	// Test a successful extraction, which replaces the installed files
+	if err := DownloadAndExtract(server.URL+"/linux-release.tar.bz2", destDir, "tar.bz2", DownloadOptions{}); err != nil {
+		t.Fatalf("DownloadAndExtract failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(destDir, "steamclient.so"))
+	if err != nil {
+		t.Fatalf("Failed to read extracted file: %v", err)
+	}
+	if string(content) != "emulator" {
+		t.Errorf("Expected content %q, got %q", "emulator", content)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "old.so")); !os.IsNotExist(err) {
+		t.Errorf("Expected the old files to be replaced, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".downloads", "linux-release.tar.bz2")); !os.IsNotExist(err) {
+		t.Errorf("Expected the archive to be removed, got %v", err)
+	}
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected only linux_release and .downloads, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,169 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// INI is an INI document, such as the emulator's configs.*.ini files. Order,
+// comments and blank lines are kept so that edited files stay readable.
+type INI struct {
+	Sections []*INISection
+}
+
+// INISection is a section of an INI document. The section with an empty name
+// holds the lines before the first section header.
+type INISection struct {
+	Name    string
+	Entries []INIEntry
+}
+
+// INIEntry is a line of an INI section. Entries with an empty Key are
+// comments or blank lines, kept verbatim in Value.
+type INIEntry struct {
+	Key, Value string
+}
+
+// ParseINI parses an INI document. Lines that are neither section headers
+// nor key=value pairs are kept verbatim.
+func ParseINI(data []byte) *INI {
+	ini := &INI{}
+	section := &INISection{}
+	ini.Sections = append(ini.Sections, section)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			section = &INISection{Name: strings.TrimSpace(trimmed[1 : len(trimmed)-1])}
+			ini.Sections = append(ini.Sections, section)
+		case trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';' || !strings.Contains(trimmed, "="):
+			section.Entries = append(section.Entries, INIEntry{Value: line})
+		default:
+			key, value, _ := strings.Cut(trimmed, "=")
+			section.Entries = append(section.Entries, INIEntry{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+		}
+	}
+	return ini
+}
+
+// ReadINIFile parses the INI file at path. A missing file yields an empty document.
+func ReadINIFile(path string) (*INI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	return ParseINI(data), nil
+}
+
+// Section returns the named section, or nil if the document has none.
+func (ini *INI) Section(name string) *INISection {
+	for _, section := range ini.Sections {
+		if section.Name == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// Get returns the value of a key in a section.
+func (ini *INI) Get(section, key string) (string, bool) {
+	if s := ini.Section(section); s != nil {
+		for _, entry := range s.Entries {
+			if entry.Key == key {
+				return entry.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Set sets the value of a key, adding the key after the section's last key
+// and the section at the end of the document as needed.
+func (ini *INI) Set(section, key, value string) {
+	s := ini.Section(section)
+	if s == nil {
+		s = &INISection{Name: section}
+		ini.Sections = append(ini.Sections, s)
+	}
+	last := -1
+	for i, entry := range s.Entries {
+		if entry.Key == key {
+			s.Entries[i].Value = value
+			return
+		}
+		if entry.Key != "" {
+			last = i
+		}
+	}
+	if last < 0 {
+		last = len(s.Entries) - 1
+		// Keep trailing blank lines after the new key
+		for last >= 0 && strings.TrimSpace(s.Entries[last].Value) == "" && s.Entries[last].Key == "" {
+			last--
+		}
+	}
+	s.Entries = append(s.Entries[:last+1], append([]INIEntry{{Key: key, Value: value}}, s.Entries[last+1:]...)...)
+}
+
+// Delete removes a key from a section.
+func (ini *INI) Delete(section, key string) {
+	if s := ini.Section(section); s != nil {
+		for i, entry := range s.Entries {
+			if entry.Key == key {
+				s.Entries = append(s.Entries[:i], s.Entries[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Merge sets every key of other in the document, overriding existing values.
+func (ini *INI) Merge(other *INI) {
+	for _, section := range other.Sections {
+		for _, entry := range section.Entries {
+			if entry.Key != "" {
+				ini.Set(section.Name, entry.Key, entry.Value)
+			}
+		}
+	}
+}
+
+// Bytes formats the document.
+func (ini *INI) Bytes() []byte {
+	var buf bytes.Buffer
+	for i, section := range ini.Sections {
+		if section.Name != "" {
+			// Separate sections by a blank line
+			if i > 0 && ini.Sections[i-1].Name != "" && !bytes.HasSuffix(buf.Bytes(), []byte("\n\n")) {
+				buf.WriteByte('\n')
+			}
+			fmt.Fprintf(&buf, "[%s]\n", section.Name)
+		}
+		for _, entry := range section.Entries {
+			if entry.Key == "" {
+				fmt.Fprintln(&buf, entry.Value)
+			} else {
+				fmt.Fprintf(&buf, "%s=%s\n", entry.Key, entry.Value)
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// WriteINIFile writes the document to path, creating its directory.
+func WriteINIFile(path string, ini *INI) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for '%s': %w", path, err)
+	}
+	if err := os.WriteFile(path, ini.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package util
+
+import "testing"
+
+const testINI = "# header comment\n[main::general]\n# avatar\nenable_account_avatar=0\n\n[main::connectivity]\nlisten_port = 47584\n"
+
+func TestINIGet(t *testing.T) {
+	ini := ParseINI([]byte(testINI))
+
+	// Test a key with spaces around the separator
+	value, ok := ini.Get("main::connectivity", "listen_port")
+	if !ok {
+		t.Fatalf("Expected listen_port to be found")
+	}
+	if value != "47584" {
+		t.Errorf("Expected listen_port 47584, got %q", value)
+	}
+
+	// Test a key from another section
+	if _, ok := ini.Get("main::general", "listen_port"); ok {
+		t.Errorf("Expected listen_port not to be found in main::general")
+	}
+}
+
+func TestINISet(t *testing.T) {
+	ini := ParseINI([]byte(testINI))
+
+	// Test replacing a value, adding a key and adding a section
+	ini.Set("main::general", "enable_account_avatar", "1")
+	ini.Set("main::general", "steam_deck", "1")
+	ini.Set("main::misc", "achievements_bypass", "1")
+
+	// Test deleting a key, keeping its section
+	ini.Delete("main::connectivity", "listen_port")
+
+	expected := "# header comment\n[main::general]\n# avatar\nenable_account_avatar=1\nsteam_deck=1\n\n[main::connectivity]\n\n[main::misc]\nachievements_bypass=1\n"
+	if got := string(ini.Bytes()); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestINIMerge(t *testing.T) {
+	ini := ParseINI([]byte("[main::misc]\nachievements_bypass=1\n"))
+	ini.Merge(ParseINI([]byte("[main::misc]\nachievements_bypass=0\n[main::stats]\nallow_unknown_stats=1\n")))
+
+	// Test that existing values are overridden
+	if value, _ := ini.Get("main::misc", "achievements_bypass"); value != "0" {
+		t.Errorf("Expected achievements_bypass to be overridden with 0, got %q", value)
+	}
+
+	// Test that missing keys are added
+	if value, _ := ini.Get("main::stats", "allow_unknown_stats"); value != "1" {
+		t.Errorf("Expected allow_unknown_stats to be added with 1, got %q", value)
+	}
+}
+
+func TestINIBytes(t *testing.T) {
+	// Test that formatting is stable across a round trip
+	formatted := ParseINI([]byte(testINI)).Bytes()
+	if again := ParseINI(formatted).Bytes(); string(again) != string(formatted) {
+		t.Errorf("Expected formatting to be stable, got:\n%s\n---\n%s", formatted, again)
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"gbe_fork_helper/config"
@@ -70,6 +72,28 @@ func BackupAndReplace(src, dest string) error {
 	return nil
 }
 
+// originalBackup returns the oldest backup BackupAndReplace made of dest,
+// which holds the file as it was before any replacement.
+func OriginalBackup(dest string) (string, error) {
+	entries, err := os.ReadDir(filepath.Dir(dest))
+	if err != nil {
+		return "", err
+	}
+	prefix := filepath.Base(dest) + "."
+	var matches []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), prefix) && strings.HasSuffix(entry.Name(), ".ORIGINAL") {
+			matches = append(matches, filepath.Join(filepath.Dir(dest), entry.Name()))
+		}
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no backup found for %s", dest)
+	}
+	// Timestamps sort chronologically
+	sort.Strings(matches)
+	return matches[0], nil
+}
+
 // copyFile is a helper function to copy a file.
 func CopyFile(src, dest string) error {
 	in, err := os.Open(src)
@@ -89,23 +113,48 @@ func CopyFile(src, dest string) error {
 }
 
 // downloadAndExtract downloads a file and extracts it.
-func DownloadAndExtract(url, destDir, format string) error {
-	resp, err := http.Get(url)
+// The archive is downloaded next to destDir so an interrupted transfer can be
+// resumed by the next call. It is extracted into a temporary directory that
+// replaces destDir only once extraction succeeded, and removed after that.
+func DownloadAndExtract(url, destDir, format string, opts DownloadOptions) error {
+	downloadDir := filepath.Join(filepath.Dir(destDir), ".downloads")
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		return err
+	}
+	archivePath := filepath.Join(downloadDir, path.Base(url))
+	if err := DownloadFile(url, archivePath, opts); err != nil {
+		return err
+	}
+
+	extractDir, err := os.MkdirTemp(filepath.Dir(destDir), "."+filepath.Base(destDir)+"-")
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	defer os.RemoveAll(extractDir)
+	if err := extractArchive(archivePath, extractDir, format); err != nil {
+		return err
+	}
 
 	if err := os.RemoveAll(destDir); err != nil {
 		return err
 	}
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+	if err := os.Rename(extractDir, destDir); err != nil {
 		return err
 	}
+	return os.Remove(archivePath)
+}
 
+// extractArchive extracts a tar.bz2 or 7z archive into destDir.
+func extractArchive(archivePath, destDir, format string) error {
 	switch format {
 	case "tar.bz2":
-		bzip2Reader := bzip2.NewReader(resp.Body)
+		archive, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+
+		bzip2Reader := bzip2.NewReader(archive)
 		tarReader := tar.NewReader(bzip2Reader)
 		for {
 			header, err := tarReader.Next()
@@ -134,71 +183,77 @@ func DownloadAndExtract(url, destDir, format string) error {
 		}
 
 		// After extraction, check if there's a single top-level directory and move its contents up
-		entries, err := os.ReadDir(destDir)
-		if err != nil {
-			return fmt.Errorf("failed to read destination directory after tar.bz2 extraction: %w", err)
-		}
-
-		if len(entries) == 1 && entries[0].IsDir() {
-			nestedDirPath := filepath.Join(destDir, entries[0].Name())
-			log.Printf("INFO: Found single nested directory '%s'. Moving contents up.", nestedDirPath)
-
-			nestedEntries, err := os.ReadDir(nestedDirPath)
-			if err != nil {
-				return fmt.Errorf("failed to read nested directory '%s': %w", nestedDirPath, err)
-			}
-
-			for _, entry := range nestedEntries {
-				oldPath := filepath.Join(nestedDirPath, entry.Name())
-				newPath := filepath.Join(destDir, entry.Name())
-				if err := os.Rename(oldPath, newPath); err != nil {
-					return fmt.Errorf("failed to move '%s' to '%s': %w", oldPath, newPath, err)
-				}
-			}
-			if err := os.Remove(nestedDirPath); err != nil {
-				return fmt.Errorf("failed to remove empty nested directory '%s': %w", nestedDirPath, err)
-			}
-			log.Println("SUCCESS: Nested directory contents moved up.")
-		}
-
-	case "7z":
-		tempFile := filepath.Join(os.TempDir(), "temp.7z")
-		outFile, err := os.Create(tempFile)
-		if err != nil {
+		if err := flattenSingleDir(destDir); err != nil {
 			return err
 		}
-		if _, err := io.Copy(outFile, resp.Body); err != nil {
-			outFile.Close()
-			return err
-		}
-		outFile.Close()
 
-		if _, err := RunCmd(config.SevenZCommand, "x", tempFile, fmt.Sprintf("-o%s", destDir), "-y"); err != nil {
-			os.Remove(tempFile)
+	case "7z":
+		if _, err := RunCmd(config.SevenZCommand, "x", archivePath, fmt.Sprintf("-o%s", destDir), "-y"); err != nil {
 			return err
 		}
 
-		// Move contents of 'release' subdirectory up
-		releasePath := filepath.Join(destDir, "release")
-		if _, err := os.Stat(releasePath); err == nil {
-			entries, err := os.ReadDir(releasePath)
-			if err != nil {
-				return err
-			}
-			for _, entry := range entries {
-				if err := os.Rename(filepath.Join(releasePath, entry.Name()), filepath.Join(destDir, entry.Name())); err != nil {
-					return err
-				}
-			}
-			if err := os.Remove(releasePath); err != nil {
+		// Move contents of the top-level 'release' or 'debug' directory up
+		for _, name := range []string{"release", "debug"} {
+			if err := moveContentsUp(destDir, name); err != nil {
 				return err
 			}
 		}
-
-		os.Remove(tempFile)
 	default:
 		return fmt.Errorf("unsupported archive format: %s", format)
 	}
 
 	return nil
 }
+
+// moveContentsUp moves the contents of the subdirectory name of destDir up
+// into destDir, if that subdirectory exists.
+func moveContentsUp(destDir, name string) error {
+	nestedDirPath := filepath.Join(destDir, name)
+	if _, err := os.Stat(nestedDirPath); err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(nestedDirPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.Rename(filepath.Join(nestedDirPath, entry.Name()), filepath.Join(destDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return os.Remove(nestedDirPath)
+}
+
+// flattenSingleDir moves the contents of destDir's only entry up into destDir when
+// that entry is a directory, as release archives wrap everything in one.
+func flattenSingleDir(destDir string) error {
+	entries, err := os.ReadDir(destDir)
+	if err != nil {
+		return fmt.Errorf("failed to read destination directory after extraction: %w", err)
+	}
+
+	if len(entries) != 1 || !entries[0].IsDir() {
+		return nil
+	}
+
+	nestedDirPath := filepath.Join(destDir, entries[0].Name())
+	log.Printf("INFO: Found single nested directory '%s'. Moving contents up.", nestedDirPath)
+
+	nestedEntries, err := os.ReadDir(nestedDirPath)
+	if err != nil {
+		return fmt.Errorf("failed to read nested directory '%s': %w", nestedDirPath, err)
+	}
+
+	for _, entry := range nestedEntries {
+		oldPath := filepath.Join(nestedDirPath, entry.Name())
+		newPath := filepath.Join(destDir, entry.Name())
+		if err := os.Rename(oldPath, newPath); err != nil {
+			return fmt.Errorf("failed to move '%s' to '%s': %w", oldPath, newPath, err)
+		}
+	}
+	if err := os.Remove(nestedDirPath); err != nil {
+		return fmt.Errorf("failed to remove empty nested directory '%s': %w", nestedDirPath, err)
+	}
+	log.Println("SUCCESS: Nested directory contents moved up.")
+	return nil
+}
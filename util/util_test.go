@@ -193,3 +193,32 @@ func TestBackupAndReplace(t *testing.T) {
 		t.Errorf("Expected new destination content %q, got %q", srcContent, string(newDestContentAfterNoBackup))
 	}
 }
+
+func TestOriginalBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	destPath := filepath.Join(tmpDir, "steam_api64.dll")
+
+	// Test when no backup exists
+	if _, err := OriginalBackup(destPath); err == nil {
+		t.Fatalf("OriginalBackup was expected to fail without backups but succeeded")
+	}
+
+	for _, name := range []string{
+		"steam_api64.dll.20250102-120000.ORIGINAL",
+		"steam_api64.dll.20240101-120000.ORIGINAL",
+		"steam_api.dll.20230101-120000.ORIGINAL",
+	} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backup, err := OriginalBackup(destPath)
+	if err != nil {
+		t.Fatalf("OriginalBackup failed: %v", err)
+	}
+	expected := filepath.Join(tmpDir, "steam_api64.dll.20240101-120000.ORIGINAL")
+	if backup != expected {
+		t.Errorf("Expected oldest backup %q, got %q", expected, backup)
+	}
+}